@@ -2,25 +2,191 @@ package schema
 
 import (
 	"fmt"
+	"mime/multipart"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type DecoderSource interface {
 	Get(source, field string) []string
 }
 
+// FileSource is an optional interface of DecoderSource which provides uploaded files, it's required by fields of type
+// *multipart.FileHeader and []*multipart.FileHeader.
+type FileSource interface {
+	Files(source, field string) []*multipart.FileHeader
+}
+
+// SeparatedSource is an optional interface of DecoderSource which stores multiple values in one string, values of slice
+// fields are split by the separator of source if field separator is not specified.
+type SeparatedSource interface {
+	Separator(source string) string
+}
+
+// CanonicalSource is an optional interface of DecoderSource whose keys are case-insensitive such as http headers, field
+// names and keys are compared after canonicalized.
+type CanonicalSource interface {
+	CanonicalName(source, name string) string
+}
+
+// NullSource is an optional interface of DecoderSource which distinguishes null from absent values such as JSON null,
+// null values of Optional fields are same as single empty value, other fields ignore them.
+type NullSource interface {
+	IsNull(source, field string) bool
+}
+
+// ErrorSource is an optional interface of DecoderSource which retrieves values lazily such as parsing request body,
+// the error occurred is returned by Decode.
+type ErrorSource interface {
+	Err() error
+}
+
+// EnumerableSource is an optional interface of DecoderSource which can list keys of source have the prefix, it's
+// required by map fields.
+type EnumerableSource interface {
+	DecoderSource
+	Keys(source, prefix string) []string
+}
+
+// MaxStructSliceLength limits element count of structure slices to avoid large allocations, indexes of enumerable
+// sources are compacted, so it's the count of distinct indexes rather than the largest one.
+const MaxStructSliceLength = 1000
+
+// Precedence decides which source is used if multiple sources of a field have values.
+type Precedence int
+
+const (
+	// PrecedenceStrict reports duplicated field values from different sources.
+	PrecedenceStrict Precedence = iota
+	// PrecedenceFirst uses the first source has values.
+	PrecedenceFirst
+	// PrecedenceLast uses the last source has values.
+	PrecedenceLast
+)
+
 type Decoder struct {
-	parser *Parser
+	parser        *Parser
+	strictSources []string
+	precedence    Precedence
+	sourceOrder   []string
 }
 
 func NewDecoder(p *Parser) (*Decoder, error) {
 	return &Decoder{parser: p}, nil
 }
 
+func (d *Decoder) splitValues(sep string, v []string) []string {
+	if sep == "" {
+		return v
+	}
+	vals := make([]string, 0, len(v))
+	for _, v := range v {
+		if v == "" {
+			continue
+		}
+		vals = append(vals, strings.Split(v, sep)...)
+	}
+	return vals
+}
+
+// sourceValues returns values of source name, values of slice fields is split by separator of SeparatedSource.
+func (d *Decoder) sourceValues(s DecoderSource, field *fieldInfo, source, name string) []string {
+	v := s.Get(source, name)
+	if len(v) == 0 || !field.IsSlice || field.Sep != "" {
+		return v
+	}
+	if ss, ok := s.(SeparatedSource); ok {
+		v = d.splitValues(ss.Separator(source), v)
+	}
+	return v
+}
+
+// SetPrecedence sets how to choose source if multiple sources of a field have values, PrecedenceStrict is the default.
+// If order is specified, field sources are sorted by it before applying precedence, sources not in order are placed
+// after in declared order.
+func (d *Decoder) SetPrecedence(precedence Precedence, order ...string) {
+	d.precedence = precedence
+	d.sourceOrder = order
+}
+
+func (d *Decoder) orderedSources(field *fieldInfo) []fieldSource {
+	if len(field.Sources) <= 1 || (len(d.sourceOrder) == 0 && d.precedence != PrecedenceLast) {
+		return field.Sources
+	}
+	sources := make([]fieldSource, len(field.Sources))
+	copy(sources, field.Sources)
+	if len(d.sourceOrder) > 0 {
+		orderOf := func(source string) int {
+			for i, s := range d.sourceOrder {
+				if s == source {
+					return i
+				}
+			}
+			return len(d.sourceOrder)
+		}
+		sort.SliceStable(sources, func(i, j int) bool {
+			return orderOf(sources[i].Source) < orderOf(sources[j].Source)
+		})
+	}
+	if d.precedence == PrecedenceLast {
+		for i, j := 0, len(sources)-1; i < j; i, j = i+1, j-1 {
+			sources[i], sources[j] = sources[j], sources[i]
+		}
+	}
+	return sources
+}
+
+// SetStrict enables strict mode for sources, unknown keys of these sources is reported as ErrUnknownKey, the
+// DecoderSource must implements EnumerableSource in strict mode, including sources routed by MultiSource.
+func (d *Decoder) SetStrict(sources ...string) {
+	d.strictSources = sources
+}
+
+func (d *Decoder) checkUnknownKeys(s DecoderSource, typInfo *structureInfo) (DecodeErrors, error) {
+	if len(d.strictSources) == 0 {
+		return nil, nil
+	}
+	var errs DecodeErrors
+	for _, source := range d.strictSources {
+		enumerable, routed := d.isEnumerable(s, source)
+		if !routed {
+			continue
+		}
+		if !enumerable {
+			return nil, fmt.Errorf("strict mode requires enumerable source")
+		}
+		for _, name := range s.(EnumerableSource).Keys(source, "") {
+			if !typInfo.hasKey(source, name, d.canonicalizer(s, source)) {
+				errs = append(errs, &FieldError{Source: source, Name: name, Values: s.Get(source, name), Err: ErrUnknownKey})
+			}
+		}
+	}
+	return errs, nil
+}
+
 func (d *Decoder) decodeStringsToType(f *fieldInfo, v []string) (reflect.Value, bool, error) {
+	if f.IsSlice {
+		v = d.splitValues(f.Sep, v)
+	}
 	l := len(v)
+	if f.IsArray {
+		if l != f.ValueType.Len() {
+			return reflect.Value{}, false, &ArrayLengthError{Length: f.ValueType.Len(), Got: l}
+		}
+		refv := reflect.New(f.ValueType).Elem()
+		for i, v := range v {
+			val, err := f.Encoding.Decode(v)
+			if err != nil {
+				return reflect.Value{}, false, err
+			}
+			refv.Index(i).Set(reflect.ValueOf(val))
+		}
+		return refv, true, nil
+	}
 	if f.IsSlice {
-		refv := reflect.MakeSlice(f.Field.Type, 0, l)
+		refv := reflect.MakeSlice(f.ValueType, 0, l)
 		for _, v := range v {
 			val, err := f.Encoding.Decode(v)
 			if err != nil {
@@ -45,49 +211,598 @@ func (d *Decoder) decodeStringsToType(f *fieldInfo, v []string) (reflect.Value,
 }
 
 func (d *Decoder) decodeField(refv reflect.Value, field *fieldInfo, v []string) (bool, error) {
+	if field.IsOptional && len(v) == 1 && v[0] == "" {
+		fieldv, _ := fieldByIndex(refv, field.Field.Index, true)
+		fieldv.Set(reflect.Zero(fieldv.Type()))
+		fieldv.Field(optionalSetIndex).SetBool(true)
+		fieldv.Field(optionalNullIndex).SetBool(true)
+		return true, nil
+	}
 	val, ok, err := d.decodeStringsToType(field, v)
 	if err != nil || !ok {
 		return false, err
 	}
-	if val.Type() != field.Field.Type {
-		return false, fmt.Errorf("different decoded value type: expect %s, but got %s", field.Field.Type, val.Type())
+	if val.Type() != field.ValueType {
+		return false, fmt.Errorf("different decoded value type: expect %s, but got %s", field.ValueType, val.Type())
+	}
+	d.setField(refv, field, val)
+	return true, nil
+}
+
+func (d *Decoder) setField(refv reflect.Value, field *fieldInfo, val reflect.Value) {
+	if field.IsPtr {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		val = ptr
 	}
 
-	fieldv := refv.FieldByIndex(field.Field.Index)
+	fieldv, _ := fieldByIndex(refv, field.Field.Index, true)
+	if field.IsOptional {
+		fieldv.Field(optionalValueIndex).Set(val)
+		fieldv.Field(optionalSetIndex).SetBool(true)
+		fieldv.Field(optionalNullIndex).SetBool(false)
+		return
+	}
 	fieldv.Set(val)
+}
+
+func (d *Decoder) isNull(s DecoderSource, source fieldSource) bool {
+	ns, ok := s.(NullSource)
+	return ok && ns.IsNull(source.Source, source.Name)
+}
+
+// canonicalizer returns the function to canonicalize names of source, it returns name itself if source isn't
+// CanonicalSource.
+func (d *Decoder) canonicalizer(s DecoderSource, source string) func(string) string {
+	cs, ok := s.(CanonicalSource)
+	if !ok {
+		return func(name string) string { return name }
+	}
+	return func(name string) string { return cs.CanonicalName(source, name) }
+}
+
+// isEnumerable checks whether keys of source can be listed, sources routed by MultiSource are checked, routed is false
+// if MultiSource doesn't route the source.
+func (d *Decoder) isEnumerable(s DecoderSource, source string) (enumerable, routed bool) {
+	switch s := s.(type) {
+	case MultiSource:
+		rs, has := s[source]
+		if !has {
+			return false, false
+		}
+		return d.isEnumerable(rs, source)
+	case enumerableIndexedSource:
+		return d.isEnumerable(s.DecoderSource, source)
+	}
+	_, ok := s.(EnumerableSource)
+	return ok, true
+}
+
+// mapEntries lists entries of map field in source, sources not routed by MultiSource have no entries.
+func (d *Decoder) mapEntries(s DecoderSource, field *fieldInfo, source fieldSource) ([]mapEntry, error) {
+	enumerable, routed := d.isEnumerable(s, source.Source)
+	if !routed {
+		return nil, nil
+	}
+	if !enumerable {
+		return nil, fmt.Errorf("map field requires enumerable source")
+	}
+	var (
+		es        = s.(EnumerableSource)
+		canonical = d.canonicalizer(s, source.Source)
+		entries   []mapEntry
+	)
+	for _, name := range es.Keys(source.Source, source.Name) {
+		key, ok := mapKey(source.Name, name)
+		if !ok {
+			key, ok = mapKey(canonical(source.Name), canonical(name))
+		}
+		if !ok {
+			continue
+		}
+		v := d.sourceValues(s, field, source.Source, name)
+		if len(v) == 0 {
+			continue
+		}
+		entries = append(entries, mapEntry{Key: key, Name: name, Values: v})
+	}
+	return entries, nil
+}
+
+func (d *Decoder) decodeMapField(refv reflect.Value, field *fieldInfo, source fieldSource, entries []mapEntry) (bool, *FieldError) {
+	m := reflect.MakeMapWithSize(field.MapType, len(entries))
+	for _, entry := range entries {
+		val, ok, err := d.decodeStringsToType(field, entry.Values)
+		if err != nil {
+			return false, d.newFieldError(field, fieldSource{Source: source.Source, Name: entry.Name}, entry.Values, err)
+		}
+		if ok {
+			m.SetMapIndex(reflect.ValueOf(entry.Key).Convert(field.MapType.Key()), val)
+		}
+	}
+	if m.Len() == 0 {
+		return false, nil
+	}
+	d.setField(refv, field, m)
 	return true, nil
 }
 
-func (d *Decoder) Decode(s DecoderSource, v interface{}) error {
-	refv := reflect.ValueOf(v)
-	if refv.Type().Kind() != reflect.Ptr {
-		return fmt.Errorf("decode destination type isn't pointer: %s", refv.Type().String())
+func (d *Decoder) newFieldError(field *fieldInfo, source fieldSource, v []string, err error) *FieldError {
+	return &FieldError{
+		Field:  field.Path,
+		Source: source.Source,
+		Name:   source.Name,
+		Values: v,
+		Err:    err,
 	}
-	refv = refv.Elem()
-	typInfo, err := d.parser.Parse(refv.Type())
+}
+
+func (d *Decoder) decodeFileField(refv reflect.Value, field *fieldInfo, files []*multipart.FileHeader) error {
+	if field.IsSlice {
+		d.setField(refv, field, reflect.ValueOf(files))
+		return nil
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("multiple files of non-slice field is not allowed: %d", len(files))
+	}
+	d.setField(refv, field, reflect.ValueOf(files[0]))
+	return nil
+}
+
+func (d *Decoder) decodeFieldFromSources(s DecoderSource, refv reflect.Value, field *fieldInfo) (fieldSource, []string, *FieldError) {
+	var (
+		updatedFrom fieldSource
+		values      []string
+	)
+	for _, source := range d.orderedSources(field) {
+		if updatedFrom.Source != "" && d.precedence != PrecedenceStrict {
+			break
+		}
+		if field.IsFile {
+			fs, ok := s.(FileSource)
+			if !ok {
+				continue
+			}
+			files := fs.Files(source.Source, source.Name)
+			if len(files) == 0 {
+				continue
+			}
+			if updatedFrom.Source != "" {
+				return updatedFrom, values, d.newFieldError(field, source, nil, fmt.Errorf("duplicated field values from different sources: %s, %s", updatedFrom, source))
+			}
+			err := d.decodeFileField(refv, field, files)
+			if err != nil {
+				return updatedFrom, values, d.newFieldError(field, source, nil, err)
+			}
+			updatedFrom = source
+			continue
+		}
+		if field.IsMap {
+			entries, err := d.mapEntries(s, field, source)
+			if err != nil {
+				return updatedFrom, values, d.newFieldError(field, source, nil, err)
+			}
+			if len(entries) == 0 {
+				continue
+			}
+			if updatedFrom.Source != "" {
+				return updatedFrom, values, d.newFieldError(field, source, nil, fmt.Errorf("duplicated field values from different sources: %s, %s", updatedFrom, source))
+			}
+			ok, fieldErr := d.decodeMapField(refv, field, source, entries)
+			if fieldErr != nil {
+				return updatedFrom, values, fieldErr
+			}
+			if ok {
+				updatedFrom = source
+			}
+			continue
+		}
+
+		v := d.sourceValues(s, field, source.Source, source.Name)
+		if len(v) == 0 && field.IsOptional && d.isNull(s, source) {
+			v = []string{""}
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if updatedFrom.Source != "" {
+			return updatedFrom, values, d.newFieldError(field, source, v, fmt.Errorf("duplicated field values from different sources: %s, %s", updatedFrom, source))
+		}
+
+		ok, err := d.decodeField(refv, field, v)
+		if err != nil {
+			return updatedFrom, values, d.newFieldError(field, source, v, err)
+		}
+		if ok {
+			updatedFrom = source
+			values = v
+		}
+	}
+	return updatedFrom, values, nil
+}
+
+// indexedSource retrieves element field values of structure slices, both prefix[index].name and prefix.index.name
+// are supported.
+type indexedSource struct {
+	DecoderSource
+	prefix string
+	index  int
+}
+
+func (s indexedSource) Get(source, name string) []string {
+	v := s.DecoderSource.Get(source, indexedName(s.prefix, s.index, name))
+	if len(v) == 0 {
+		v = s.DecoderSource.Get(source, s.prefix+"."+strconv.Itoa(s.index)+"."+name)
+	}
+	return v
+}
+
+func (s indexedSource) Files(source, name string) []*multipart.FileHeader {
+	fs, ok := s.DecoderSource.(FileSource)
+	if !ok {
+		return nil
+	}
+	files := fs.Files(source, indexedName(s.prefix, s.index, name))
+	if len(files) == 0 {
+		files = fs.Files(source, s.prefix+"."+strconv.Itoa(s.index)+"."+name)
+	}
+	return files
+}
+
+func (s indexedSource) IsNull(source, name string) bool {
+	ns, ok := s.DecoderSource.(NullSource)
+	if !ok {
+		return false
+	}
+	return ns.IsNull(source, indexedName(s.prefix, s.index, name)) ||
+		ns.IsNull(source, s.prefix+"."+strconv.Itoa(s.index)+"."+name)
+}
+
+func (s indexedSource) CanonicalName(source, name string) string {
+	cs, ok := s.DecoderSource.(CanonicalSource)
+	if !ok {
+		return name
+	}
+	return cs.CanonicalName(source, name)
+}
+
+func (s indexedSource) Separator(source string) string {
+	if ss, ok := s.DecoderSource.(SeparatedSource); ok {
+		return ss.Separator(source)
+	}
+	return ""
+}
+
+type enumerableIndexedSource struct {
+	indexedSource
+}
+
+func (s enumerableIndexedSource) Keys(source, prefix string) []string {
+	var keys []string
+	for _, p := range []string{indexedName(s.prefix, s.index, ""), s.prefix + "." + strconv.Itoa(s.index) + "."} {
+		for _, key := range s.DecoderSource.(EnumerableSource).Keys(source, p+prefix) {
+			key = key[len(p):]
+			if !hasString(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func (d *Decoder) newIndexedSource(s DecoderSource, prefix string, index int) DecoderSource {
+	is := indexedSource{DecoderSource: s, prefix: prefix, index: index}
+	if _, ok := s.(EnumerableSource); ok {
+		return enumerableIndexedSource{indexedSource: is}
+	}
+	return is
+}
+
+// structSliceIndexes returns sorted element indexes present in source if all routed sources are enumerable, sparse
+// indexes are compacted so that element count never exceeds the count of keys. Otherwise false is returned and elements
+// are probed until one has no values.
+func (d *Decoder) structSliceIndexes(s DecoderSource, slice *structSliceInfo) ([]int, bool, error) {
+	for _, source := range d.parser.validSources {
+		if enumerable, routed := d.isEnumerable(s, source); routed && !enumerable {
+			return nil, false, nil
+		}
+	}
+	var (
+		es      = s.(EnumerableSource)
+		indexes []int
+		seen    = make(map[int]bool)
+	)
+	for _, source := range d.parser.validSources {
+		for _, name := range es.Keys(source, slice.Name) {
+			index, _, ok := sliceIndex(slice.Name, name)
+			if !ok {
+				continue
+			}
+			if seen[index] {
+				continue
+			}
+			if len(indexes) >= MaxStructSliceLength {
+				return nil, true, fmt.Errorf("too many slice elements, max: %d", MaxStructSliceLength)
+			}
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes, true, nil
+}
+
+// elemHook runs hooks of a structure slice element found in parent structure, it's deferred until the whole decoding
+// succeed.
+type elemHook func(parent reflect.Value) DecodeErrors
+
+func (d *Decoder) decodeStructSlice(s DecoderSource, refv reflect.Value, slice *structSliceInfo, result *DecodeResult, hooks *[]elemHook) (bool, DecodeErrors) {
+	indexes, enumerable, err := d.structSliceIndexes(s, slice)
 	if err != nil {
-		return err
+		return false, DecodeErrors{{Field: slice.Path, Name: slice.Name, Err: err}}
 	}
 
+	var (
+		errs  DecodeErrors
+		elemt = slice.Field.Type.Elem()
+		refs  = reflect.MakeSlice(slice.Field.Type, 0, len(indexes))
+	)
+	for i := 0; !enumerable || i < len(indexes); i++ {
+		if i >= MaxStructSliceLength {
+			errs = append(errs, &FieldError{Field: slice.Path, Name: slice.Name, Err: fmt.Errorf("too many slice elements, max: %d", MaxStructSliceLength)})
+			break
+		}
+		index := i
+		if enumerable {
+			index = indexes[i]
+		}
+		var (
+			elemv      = reflect.New(elemt).Elem()
+			elemResult *DecodeResult
+		)
+		if result != nil {
+			elemResult = newDecodeResult()
+		}
+		var elemHooks []elemHook
+		updated, elemErrs := d.decodeStructure(d.newIndexedSource(s, slice.Name, index), elemv, slice.Elem, elemResult, &elemHooks)
+		if !enumerable && updated == 0 {
+			break
+		}
+		elemPath := slice.Path + "[" + strconv.Itoa(i) + "]."
+		prefixErrs := func(errs DecodeErrors) DecodeErrors {
+			for _, err := range errs {
+				if err.Field == "" {
+					err.Field = strings.TrimSuffix(elemPath, ".")
+				} else {
+					err.Field = elemPath + err.Field
+				}
+				if err.Name != "" {
+					err.Name = indexedName(slice.Name, index, err.Name)
+				}
+			}
+			return errs
+		}
+		prefixErrs(elemErrs)
+		elemHooks = append(elemHooks, func(elemv reflect.Value) DecodeErrors {
+			return d.runDecodeHooks(elemv, slice.Elem)
+		})
+		for _, hook := range elemHooks {
+			*hooks = append(*hooks, func(parent reflect.Value) DecodeErrors {
+				fieldv, _ := fieldByIndex(parent, slice.Field.Index, false)
+				return prefixErrs(hook(fieldv.Index(i)))
+			})
+		}
+		if elemResult != nil {
+			for path, fr := range elemResult.Fields {
+				if fr.Name != "" {
+					fr.Name = indexedName(slice.Name, index, fr.Name)
+				}
+				result.Fields[elemPath+path] = fr
+			}
+		}
+		errs = append(errs, elemErrs...)
+		refs = reflect.Append(refs, elemv)
+	}
+	if refs.Len() == 0 {
+		return false, errs
+	}
+	fieldv, _ := fieldByIndex(refv, slice.Field.Index, true)
+	fieldv.Set(refs)
+	return true, errs
+}
+
+func (d *Decoder) decodeStructure(s DecoderSource, refv reflect.Value, typInfo *structureInfo, result *DecodeResult, hooks *[]elemHook) (updated int, errs DecodeErrors) {
 	for i := range typInfo.fields {
 		field := &typInfo.fields[i]
 
-		var updatedFrom fieldSource
-		for _, source := range field.Sources {
-			v := s.Get(source.Source, source.Name)
-			if len(v) == 0 {
-				continue
+		updatedFrom, v, err := d.decodeFieldFromSources(s, refv, field)
+		if err != nil {
+			updated++
+			errs = append(errs, err)
+			continue
+		}
+		if updatedFrom.Source != "" {
+			updated++
+			if err := d.validateField(refv, field); err != nil {
+				errs = append(errs, d.newFieldError(field, updatedFrom, v, err))
 			}
-			if updatedFrom.Source != "" {
-				return fmt.Errorf("duplicated field values from different sources: %s, %s", updatedFrom, source)
+			if result != nil {
+				result.Fields[field.Path] = FieldResult{Source: updatedFrom.Source, Name: updatedFrom.Name, Values: v}
 			}
-
+			continue
+		}
+		if field.HasDefault {
+			v := []string{field.Default}
 			ok, err := d.decodeField(refv, field, v)
 			if err != nil {
-				return fmt.Errorf("invalid field values: %s, %s", source, err.Error())
+				errs = append(errs, d.newFieldError(field, field.Sources[0], v, fmt.Errorf("invalid default value: %s", err.Error())))
+			} else if ok {
+				if err := d.validateField(refv, field); err != nil {
+					errs = append(errs, d.newFieldError(field, field.Sources[0], v, err))
+				}
+				if result != nil {
+					result.Fields[field.Path] = FieldResult{Values: v, Default: true}
+				}
 			}
-			if ok {
-				updatedFrom = source
+		} else if field.Required {
+			errs = append(errs, d.newFieldError(field, field.Sources[0], nil, ErrRequired))
+		}
+	}
+	for i := range typInfo.structSlices {
+		slice := &typInfo.structSlices[i]
+		ok, sliceErrs := d.decodeStructSlice(s, refv, slice, result, hooks)
+		if ok || len(sliceErrs) > 0 {
+			updated++
+		}
+		errs = append(errs, sliceErrs...)
+	}
+	return updated, errs
+}
+
+// FieldResult records where the value of a field comes from, Values is nil for map and file fields.
+type FieldResult struct {
+	Source  string
+	Name    string
+	Values  []string
+	Default bool
+}
+
+// DecodeResult records decoded fields keyed by field path such as "Embed.Embed" and "Items[0].Name", fields not
+// recorded are untouched by decoder.
+type DecodeResult struct {
+	Fields map[string]FieldResult
+}
+
+func newDecodeResult() *DecodeResult {
+	return &DecodeResult{Fields: make(map[string]FieldResult)}
+}
+
+// Has checks whether the field is provided by sources, default values is not counted.
+func (r *DecodeResult) Has(path string) bool {
+	fr, has := r.Fields[path]
+	return has && !fr.Default
+}
+
+// Decode binds source values to v, errors of all fields are collected into DecodeErrors. If there is no error,
+// AfterDecoder and Validator implemented by v, nested structures and structure slice elements are called bottom-up.
+func (d *Decoder) Decode(s DecoderSource, v interface{}) error {
+	return d.decode(s, v, nil)
+}
+
+// DecodeWithResult is same as Decode, and also returns where the value of each field comes from, the result is
+// returned even if decode failed.
+func (d *Decoder) DecodeWithResult(s DecoderSource, v interface{}) (*DecodeResult, error) {
+	result := newDecodeResult()
+	err := d.decode(s, v, result)
+	return result, err
+}
+
+func (d *Decoder) decode(s DecoderSource, v interface{}, result *DecodeResult) error {
+	refv := reflect.ValueOf(v)
+	if refv.Type().Kind() != reflect.Ptr {
+		return fmt.Errorf("decode destination type isn't pointer: %s", refv.Type().String())
+	}
+	refv = refv.Elem()
+	typInfo, err := d.parser.parseStructure(refv.Type())
+	if err != nil {
+		return err
+	}
+
+	errs, err := d.checkUnknownKeys(s, typInfo)
+	if err != nil {
+		return err
+	}
+	var hooks []elemHook
+	_, fieldErrs := d.decodeStructure(s, refv, typInfo, result, &hooks)
+	if es, ok := s.(ErrorSource); ok {
+		if err := es.Err(); err != nil {
+			return err
+		}
+	}
+	errs = append(errs, fieldErrs...)
+	if len(errs) == 0 {
+		// hooks of slice elements are collected bottom-up, they run before hooks of the parent structures.
+		for _, hook := range hooks {
+			errs = append(errs, hook(refv)...)
+		}
+	}
+	if len(errs) == 0 {
+		errs = d.runDecodeHooks(refv, typInfo)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MultiSource routes source names to different DecoderSources, optional interfaces are forwarded to the routed source.
+type MultiSource map[string]DecoderSource
+
+var (
+	_ EnumerableSource = MultiSource(nil)
+	_ FileSource       = MultiSource(nil)
+	_ SeparatedSource  = MultiSource(nil)
+	_ CanonicalSource  = MultiSource(nil)
+	_ NullSource       = MultiSource(nil)
+	_ ErrorSource      = MultiSource(nil)
+)
+
+func (m MultiSource) Get(source, name string) []string {
+	s, has := m[source]
+	if !has {
+		return nil
+	}
+	return s.Get(source, name)
+}
+
+func (m MultiSource) Keys(source, prefix string) []string {
+	s, ok := m[source].(EnumerableSource)
+	if !ok {
+		return nil
+	}
+	return s.Keys(source, prefix)
+}
+
+func (m MultiSource) Files(source, name string) []*multipart.FileHeader {
+	s, ok := m[source].(FileSource)
+	if !ok {
+		return nil
+	}
+	return s.Files(source, name)
+}
+
+func (m MultiSource) CanonicalName(source, name string) string {
+	s, ok := m[source].(CanonicalSource)
+	if !ok {
+		return name
+	}
+	return s.CanonicalName(source, name)
+}
+
+func (m MultiSource) Separator(source string) string {
+	s, ok := m[source].(SeparatedSource)
+	if !ok {
+		return ""
+	}
+	return s.Separator(source)
+}
+
+func (m MultiSource) IsNull(source, name string) bool {
+	s, ok := m[source].(NullSource)
+	return ok && s.IsNull(source, name)
+}
+
+// Err returns the first error of routed sources implement ErrorSource, sources are checked in sorted order of names.
+func (m MultiSource) Err() error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if s, ok := m[name].(ErrorSource); ok {
+			if err := s.Err(); err != nil {
+				return err
 			}
 		}
 	}
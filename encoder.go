@@ -3,6 +3,8 @@ package schema
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 type EncoderDestination interface {
@@ -32,6 +34,9 @@ func (e *Encoder) encodeTypeToStrings(f *fieldInfo, v reflect.Value) ([]string,
 			}
 			vals = append(vals, s)
 		}
+		if f.Sep != "" {
+			return []string{strings.Join(vals, f.Sep)}, nil
+		}
 		return vals, nil
 	}
 	s, err := f.Encoding.Encode(v.Interface())
@@ -44,25 +49,138 @@ func (e *Encoder) encodeTypeToStrings(f *fieldInfo, v reflect.Value) ([]string,
 	return []string{s}, nil
 }
 
+// fieldValue returns the value to encode, null is true for Optional field which is set to null.
+func (e *Encoder) fieldValue(refv reflect.Value, field *fieldInfo) (v reflect.Value, null, ok bool) {
+	fieldv, ok := fieldByIndex(refv, field.Field.Index, false)
+	if !ok {
+		return reflect.Value{}, false, false
+	}
+	if field.IsOptional {
+		if !fieldv.Field(optionalSetIndex).Bool() {
+			return reflect.Value{}, false, false
+		}
+		if fieldv.Field(optionalNullIndex).Bool() {
+			return reflect.Value{}, true, true
+		}
+		fieldv = fieldv.Field(optionalValueIndex)
+	}
+	if field.IsPtr {
+		if fieldv.IsNil() {
+			return reflect.Value{}, false, false
+		}
+		fieldv = fieldv.Elem()
+	}
+	return fieldv, false, true
+}
+
 func (e *Encoder) encodeField(refv reflect.Value, field *fieldInfo) (v []string, err error) {
-	fieldv := refv.FieldByIndex(field.Field.Index)
+	fieldv, null, ok := e.fieldValue(refv, field)
+	if !ok {
+		return nil, nil
+	}
+	if null {
+		return []string{""}, nil
+	}
 	return e.encodeTypeToStrings(field, fieldv)
 }
 
-func (e *Encoder) Encode(v interface{}, dst EncoderDestination) error {
-	refv := reflect.ValueOf(v)
-	if refv.Type().Kind() == reflect.Ptr {
-		refv = refv.Elem()
+func (e *Encoder) encodeMapField(refv reflect.Value, field *fieldInfo) ([]mapEntry, error) {
+	fieldv, null, ok := e.fieldValue(refv, field)
+	if !ok || null || fieldv.Len() == 0 {
+		return nil, nil
 	}
-	reft := refv.Type()
-	typInfo, err := e.parser.Parse(reft)
-	if err != nil {
-		return err
+	keys := fieldv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	entries := make([]mapEntry, 0, len(keys))
+	for _, key := range keys {
+		vals, err := e.encodeTypeToStrings(field, fieldv.MapIndex(key))
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		entries = append(entries, mapEntry{Key: key.String(), Values: vals})
 	}
+	return entries, nil
+}
 
-	refv = reflect.Indirect(refv)
+func (e *Encoder) setField(dst EncoderDestination, field *fieldInfo, key string, vals []string) error {
+	var (
+		ok  bool
+		err error
+	)
+	for _, source := range field.Sources {
+		if field.IsMap {
+			source.Name = mapName(source.Name, key)
+		}
+		ok, err = dst.Set(source.Source, source.Name, vals)
+		if err != nil {
+			return fmt.Errorf("set field failed: %s, %s, %v, %s", field.Field.Name, source, vals, err.Error())
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("cann't set to destination: %s, %s, %v", field.Field.Name, field.Sources[0], vals)
+}
+
+// indexedDestination sets element field values of structure slices with name prefix[index].name.
+type indexedDestination struct {
+	EncoderDestination
+	prefix string
+	index  int
+}
+
+func (d indexedDestination) Set(source, name string, v []string) (bool, error) {
+	return d.EncoderDestination.Set(source, indexedName(d.prefix, d.index, name), v)
+}
+
+func (e *Encoder) encodeStructSlice(refv reflect.Value, slice *structSliceInfo, dst EncoderDestination) error {
+	fieldv, ok := fieldByIndex(refv, slice.Field.Index, false)
+	if !ok {
+		return nil
+	}
+	for i, l := 0, fieldv.Len(); i < l; i++ {
+		elemv := fieldv.Index(i)
+		if len(slice.Elem.hooks) > 0 {
+			elemv = reflect.New(elemv.Type()).Elem()
+			elemv.Set(fieldv.Index(i))
+			err := e.runEncodeHooks(elemv, slice.Elem)
+			if err != nil {
+				return err
+			}
+		}
+		err := e.encodeStructure(elemv, slice.Elem, indexedDestination{EncoderDestination: dst, prefix: slice.Name, index: i})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeStructure(refv reflect.Value, typInfo *structureInfo, dst EncoderDestination) error {
 	for i := range typInfo.fields {
 		field := &typInfo.fields[i]
+		if field.IsFile {
+			continue
+		}
+		if field.IsMap {
+			entries, err := e.encodeMapField(refv, field)
+			if err != nil {
+				return fmt.Errorf("encode field failed: %s, %s, %s", field.Field.Name, field.Sources[0], err.Error())
+			}
+			for _, entry := range entries {
+				err = e.setField(dst, field, entry.Key, entry.Values)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		vals, err := e.encodeField(refv, field)
 		if err != nil {
 			return fmt.Errorf("encode field failed: %s, %s, %s", field.Field.Name, field.Sources[0], err.Error())
@@ -70,20 +188,43 @@ func (e *Encoder) Encode(v interface{}, dst EncoderDestination) error {
 		if len(vals) == 0 {
 			continue
 		}
-
-		var ok bool
-		for _, source := range field.Sources {
-			ok, err = dst.Set(source.Source, source.Name, vals)
-			if err != nil {
-				return fmt.Errorf("set field failed: %s, %s, %v, %s", field.Field.Name, source, vals, err.Error())
-			}
-			if ok {
-				break
-			}
+		err = e.setField(dst, field, "", vals)
+		if err != nil {
+			return err
 		}
-		if !ok {
-			return fmt.Errorf("cann't set to destination: %s, %s, %v", field.Field.Name, field.Sources[0], vals)
+	}
+	for i := range typInfo.structSlices {
+		err := e.encodeStructSlice(refv, &typInfo.structSlices[i], dst)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
+
+// Encode writes field values of v to dst, BeforeEncoder implemented by v and nested structures is called top-down on
+// a copy of v, structures referenced by pointers are copied before their hooks are called, so assigning fields in hooks
+// doesn't modify v, but elements of slices and maps are still shared.
+func (e *Encoder) Encode(v interface{}, dst EncoderDestination) error {
+	refv := reflect.ValueOf(v)
+	if refv.Type().Kind() == reflect.Ptr {
+		refv = refv.Elem()
+	}
+	reft := refv.Type()
+	typInfo, err := e.parser.parseStructure(reft)
+	if err != nil {
+		return err
+	}
+
+	refv = reflect.Indirect(refv)
+	if len(typInfo.hooks) > 0 {
+		cp := reflect.New(reft).Elem()
+		cp.Set(refv)
+		refv = cp
+		err = e.runEncodeHooks(refv, typInfo)
+		if err != nil {
+			return err
+		}
+	}
+	return e.encodeStructure(refv, typInfo, dst)
+}
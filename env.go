@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvName converts name to upper snake case environment variable name with prefix, e.g. "db.maxConns" is converted
+// to "APP_DB_MAX_CONNS" with prefix "APP".
+func EnvName(prefix, name string) string {
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	var (
+		buf   strings.Builder
+		runes = []rune(name)
+	)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = '_'
+		} else if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				buf.WriteByte('_')
+			}
+		}
+		if r == '_' && (buf.Len() == 0 || strings.HasSuffix(buf.String(), "_")) {
+			continue
+		}
+		buf.WriteRune(unicode.ToUpper(r))
+	}
+	return strings.TrimSuffix(buf.String(), "_")
+}
+
+// EnvMap converts environ in the form "key=value" such as os.Environ() to map.
+func EnvMap(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 {
+			continue
+		}
+		env[kv[:i]] = kv[i+1:]
+	}
+	return env
+}
+
+// EnvSource is a DecoderSource for environment variables, names are converted by EnvName. Values of slice fields
+// are split by the separator.
+type EnvSource struct {
+	source string
+	prefix string
+	sep    string
+	env    map[string]string
+}
+
+var (
+	_ EnumerableSource = (*EnvSource)(nil)
+	_ SeparatedSource  = (*EnvSource)(nil)
+	_ CanonicalSource  = (*EnvSource)(nil)
+)
+
+// NewEnvSource creates source for environment variables, env is loaded from os.Environ if it's nil.
+func NewEnvSource(source, prefix, sep string, env map[string]string) *EnvSource {
+	if env == nil {
+		env = EnvMap(os.Environ())
+	}
+	return &EnvSource{
+		source: source,
+		prefix: prefix,
+		sep:    sep,
+		env:    env,
+	}
+}
+
+func (s *EnvSource) Get(source, name string) []string {
+	if source != s.source {
+		return nil
+	}
+	v, has := s.env[EnvName(s.prefix, name)]
+	if !has {
+		return nil
+	}
+	return []string{v}
+}
+
+func (s *EnvSource) Separator(source string) string {
+	if source != s.source {
+		return ""
+	}
+	return s.sep
+}
+
+// CanonicalName converts name by EnvName without prefix, so that variable names listed by Keys in strict mode are
+// matched with field names.
+func (s *EnvSource) CanonicalName(source, name string) string {
+	if source != s.source {
+		return name
+	}
+	return EnvName("", name)
+}
+
+// Keys lists environment variable names prefixed by converted prefix, the remaining part is returned as sub name of
+// prefix: "labels" matches APP_LABELS_ENV as "labels.ENV", and APP_ITEMS_0_NAME as "items.0.NAME".
+func (s *EnvSource) Keys(source, prefix string) []string {
+	if source != s.source {
+		return nil
+	}
+	envPrefix := EnvName(s.prefix, prefix)
+	if envPrefix != "" {
+		envPrefix += "_"
+	}
+	var keys []string
+	for key := range s.env {
+		if !strings.HasPrefix(key, envPrefix) || len(key) == len(envPrefix) {
+			continue
+		}
+		rest := key[len(envPrefix):]
+		if i := strings.IndexByte(rest, '_'); i > 0 && strings.Trim(rest[:i], "0123456789") == "" {
+			rest = rest[:i] + "." + rest[i+1:]
+		}
+		switch {
+		case prefix == "" || isMapKeySeparator(prefix[len(prefix)-1]):
+			keys = append(keys, prefix+rest)
+		default:
+			keys = append(keys, prefix+"."+rest)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EnvDestination is an EncoderDestination collects environment variables, names are converted by EnvName and
+// multiple values are joined by the separator.
+type EnvDestination struct {
+	source string
+	prefix string
+	sep    string
+	values map[string]string
+}
+
+var _ EncoderDestination = (*EnvDestination)(nil)
+
+func NewEnvDestination(source, prefix, sep string) *EnvDestination {
+	return &EnvDestination{
+		source: source,
+		prefix: prefix,
+		sep:    sep,
+		values: make(map[string]string),
+	}
+}
+
+func (d *EnvDestination) Set(source, name string, v []string) (bool, error) {
+	if source != d.source {
+		return false, nil
+	}
+	if len(v) > 1 && d.sep == "" {
+		return false, fmt.Errorf("separator is required for multiple values: %v", v)
+	}
+	d.values[EnvName(d.prefix, name)] = strings.Join(v, d.sep)
+	return true, nil
+}
+
+// Values returns the collected environment variables.
+func (d *EnvDestination) Values() map[string]string {
+	return d.values
+}
+
+func (d *EnvDestination) quote(v string) string {
+	for _, r := range v {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.,:/@+", r) {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
+
+// WriteTo writes variables sorted by name in .env format.
+func (d *EnvDestination) WriteTo(w io.Writer) (int64, error) {
+	names := make([]string, 0, len(d.values))
+	for name := range d.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	for _, name := range names {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(d.quote(d.values[name]))
+		buf.WriteByte('\n')
+	}
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
@@ -0,0 +1,141 @@
+package schema_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func newConfigParser(t *testing.T) *schema.Parser {
+	p, err := schema.NewParser("schema", []string{"flag", "env", "file"}, func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return strings.ToLower(name[:1]) + name[1:]
+	})
+	if err == nil {
+		err = p.RegisterTypes(schema.BuiltinTypes()...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEnvName(t *testing.T) {
+	for name, expect := range map[string]string{
+		"db.host":       "APP_DB_HOST",
+		"db.maxConns":   "APP_DB_MAX_CONNS",
+		"DBHost":        "APP_DB_HOST",
+		"items[0].name": "APP_ITEMS_0_NAME",
+		"labels[env]":   "APP_LABELS_ENV",
+		"http2Enabled":  "APP_HTTP2_ENABLED",
+	} {
+		if got := schema.EnvName("APP", name); got != expect {
+			t.Errorf("unexpected env name of %s: expect %s, but got %s", name, expect, got)
+		}
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	type DB struct {
+		Host     string `schema:"env"`
+		MaxConns int    `schema:"env"`
+	}
+	type Server struct {
+		Name string `schema:"env"`
+		Port uint16 `schema:"env"`
+	}
+	type Config struct {
+		Database DB
+		Hosts    []string          `schema:"env"`
+		Ports    []int             `schema:"env;sep=|"`
+		Labels   map[string]string `schema:"env"`
+		Servers  []Server
+		Debug    bool   `schema:"env"`
+		Motto    string `schema:"env"`
+	}
+	env := map[string]string{
+		"APP_DATABASE_HOST":      "localhost",
+		"APP_DATABASE_MAX_CONNS": "10",
+		"APP_HOSTS":              "a,b",
+		"APP_PORTS":              "80|443",
+		"APP_LABELS_ENV":         "dev",
+		"APP_SERVERS_0_NAME":     "x",
+		"APP_SERVERS_0_PORT":     "8080",
+		"APP_SERVERS_1_NAME":     "y",
+		"APP_SERVERS_1_PORT":     "8081",
+		"APP_DEBUG":              "true",
+		"APP_MOTTO":              "hello world",
+		"OTHER_APP_DB_HOST":      "other",
+		"APP_UNKNOWN_VARIABLES":  "",
+	}
+	expectData := Config{
+		Database: DB{Host: "localhost", MaxConns: 10},
+		Hosts:    []string{"a", "b"},
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"ENV": "dev"},
+		Servers:  []Server{{Name: "x", Port: 8080}, {Name: "y", Port: 8081}},
+		Debug:    true,
+		Motto:    "hello world",
+	}
+
+	p := newConfigParser(t)
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data Config
+	err = d.Decode(schema.NewEnvSource("env", "APP", ",", env), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	d.SetStrict("env")
+	data = Config{}
+	err = d.Decode(schema.NewEnvSource("env", "APP", ",", env), &data)
+	var errs schema.DecodeErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Name != "UNKNOWN_VARIABLES" || !errors.Is(err, schema.ErrUnknownKey) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	e, err := schema.NewEncoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := schema.NewEnvDestination("env", "APP", ",")
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_, err = dst.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFile := `APP_DATABASE_HOST=localhost
+APP_DATABASE_MAX_CONNS=10
+APP_DEBUG=true
+APP_HOSTS=a,b
+APP_LABELS_ENV=dev
+APP_MOTTO="hello world"
+APP_PORTS="80|443"
+APP_SERVERS_0_NAME=x
+APP_SERVERS_0_PORT=8080
+APP_SERVERS_1_NAME=y
+APP_SERVERS_1_PORT=8081
+`
+	if buf.String() != expectFile {
+		t.Fatalf("unexpected env file: %s", buf.String())
+	}
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRequired is reported when none of the sources of a required field has value.
+var ErrRequired = errors.New("field is required")
+
+// ErrUnknownKey is reported in strict mode when source has keys doesn't belong to any field.
+var ErrUnknownKey = errors.New("unknown key")
+
+// ArrayLengthError is reported when count of values doesn't match the length of array field.
+type ArrayLengthError struct {
+	Length int
+	Got    int
+}
+
+func (e *ArrayLengthError) Error() string {
+	return fmt.Sprintf("array length mismatched: expect %d values, but got %d", e.Length, e.Got)
+}
+
+// FieldError describes a failure of a single field, Field is the go field path such as "Embed.Embed", Source and Name
+// is the source and key where the values come from, both are empty for errors returned by hooks.
+type FieldError struct {
+	Field  string
+	Source string
+	Name   string
+	Values []string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	var where []string
+	if e.Field != "" {
+		where = append(where, e.Field)
+	}
+	if e.Source != "" || e.Name != "" {
+		where = append(where, fieldSource{Source: e.Source, Name: e.Name}.String())
+	}
+	if len(where) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(where, " "), e.Err.Error())
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors collects all field errors of one decoding.
+type DecodeErrors []*FieldError
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid field values: " + strings.Join(msgs, "; ")
+}
+
+func (e DecodeErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrHelp is returned by NewFlagSource if -h or --help is provided but not defined.
+var ErrHelp = errors.New("flag: help requested")
+
+// FlagSource is a DecoderSource for command line arguments in the forms: --name=value, --name value, and --name for
+// bool fields, single dash is also allowed. Flags can be repeated for slice fields. Arguments not started with dash or
+// after "--" are positional.
+type FlagSource struct {
+	source string
+	values map[string][]string
+	keys   []string
+	args   []string
+}
+
+var _ EnumerableSource = (*FlagSource)(nil)
+
+// NewFlagSource parses args by flags of source defined in structure t, names are computed by parser.
+func NewFlagSource(p *Parser, t reflect.Type, source string, args []string) (*FlagSource, error) {
+	typInfo, err := p.parseStructure(t)
+	if err != nil {
+		return nil, err
+	}
+
+	s := FlagSource{
+		source: source,
+		values: make(map[string][]string),
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			s.args = append(s.args, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			s.args = append(s.args, arg)
+			continue
+		}
+
+		name := strings.TrimPrefix(arg[1:], "-")
+		var (
+			value    string
+			hasValue bool
+		)
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name, value, hasValue = name[:i], name[i+1:], true
+		}
+		field, ok := typInfo.findKey(source, name, nil)
+		if !ok {
+			if name == "h" || name == "help" {
+				return nil, ErrHelp
+			}
+			return nil, fmt.Errorf("unknown flag: %s", arg)
+		}
+		if !hasValue {
+			if field.ValueType.Kind() == reflect.Bool {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag needs an argument: %s", arg)
+			}
+		}
+		if _, has := s.values[name]; !has {
+			s.keys = append(s.keys, name)
+		}
+		s.values[name] = append(s.values[name], value)
+	}
+	sort.Strings(s.keys)
+	return &s, nil
+}
+
+// Args returns positional arguments.
+func (s *FlagSource) Args() []string {
+	return s.args
+}
+
+func (s *FlagSource) Get(source, name string) []string {
+	if source != s.source {
+		return nil
+	}
+	return s.values[name]
+}
+
+func (s *FlagSource) Keys(source, prefix string) []string {
+	if source != s.source {
+		return nil
+	}
+	var keys []string
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func writeFieldsUsage(w *strings.Builder, typInfo *structureInfo, source, descTag, prefix string) {
+	for i := range typInfo.fields {
+		field := &typInfo.fields[i]
+		for _, src := range field.Sources {
+			if src.Source != source {
+				continue
+			}
+
+			name := prefix + src.Name
+			if field.IsMap {
+				name = mapName(name, "key")
+			}
+			w.WriteString("  --")
+			w.WriteString(name)
+			if field.ValueType.Kind() != reflect.Bool {
+				w.WriteString(" ")
+				w.WriteString(field.ValueType.String())
+			}
+			w.WriteString("\n")
+
+			var usage []string
+			if descTag != "" {
+				if desc := field.Field.Tag.Get(descTag); desc != "" {
+					usage = append(usage, desc)
+				}
+			}
+			if field.HasDefault {
+				usage = append(usage, "(default "+strconv.Quote(field.Default)+")")
+			}
+			if field.Required {
+				usage = append(usage, "(required)")
+			}
+			if len(usage) > 0 {
+				w.WriteString("    \t")
+				w.WriteString(strings.Join(usage, " "))
+				w.WriteString("\n")
+			}
+			break
+		}
+	}
+	for i := range typInfo.structSlices {
+		slice := &typInfo.structSlices[i]
+		writeFieldsUsage(w, slice.Elem, source, descTag, prefix+slice.Name+"[N].")
+	}
+}
+
+// WriteFlagUsage writes help of flags of source defined in structure t, descTag is the tag name of field description.
+func WriteFlagUsage(w io.Writer, p *Parser, t reflect.Type, source, descTag string) error {
+	typInfo, err := p.parseStructure(t)
+	if err != nil {
+		return err
+	}
+	var buf strings.Builder
+	writeFieldsUsage(&buf, typInfo, source, descTag, "")
+	_, err = io.WriteString(w, buf.String())
+	return err
+}
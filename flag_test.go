@@ -0,0 +1,87 @@
+package schema_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func TestFlagSource(t *testing.T) {
+	type Server struct {
+		Name string `schema:"flag"`
+	}
+	type Config struct {
+		Host    string            `schema:"flag;default=localhost" desc:"listen host"`
+		Port    int               `schema:"flag;required" desc:"listen port"`
+		Verbose bool              `schema:"flag"`
+		Debug   *bool             `schema:"flag"`
+		Tags    []string          `schema:"flag"`
+		Labels  map[string]string `schema:"flag"`
+		Servers []Server
+	}
+	p := newConfigParser(t)
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"run", "--port", "80", "--verbose", "-debug=false", "--tags=a", "--tags", "b", "--labels[env]=dev", "--servers[0].name", "x", "--", "--host"}
+	src, err := schema.NewFlagSource(p, reflect.TypeOf(Config{}), "flag", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data Config
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug := false
+	expectData := Config{
+		Host:    "localhost",
+		Port:    80,
+		Verbose: true,
+		Debug:   &debug,
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"env": "dev"},
+		Servers: []Server{{Name: "x"}},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+	if !reflect.DeepEqual(src.Args(), []string{"run", "--host"}) {
+		t.Fatalf("unexpected positional arguments: %v", src.Args())
+	}
+
+	for _, args := range [][]string{{"--unknown"}, {"--port"}} {
+		_, err = schema.NewFlagSource(p, reflect.TypeOf(Config{}), "flag", args)
+		if err == nil {
+			t.Fatalf("invalid args should fail: %v", args)
+		}
+	}
+	_, err = schema.NewFlagSource(p, reflect.TypeOf(Config{}), "flag", []string{"-h"})
+	if !errors.Is(err, schema.ErrHelp) {
+		t.Fatalf("expect ErrHelp, but got %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = schema.WriteFlagUsage(&buf, p, reflect.TypeOf(Config{}), "flag", "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectUsage := `  --host string
+    	listen host (default "localhost")
+  --port int
+    	listen port (required)
+  --verbose
+  --debug
+  --tags []string
+  --labels[key] string
+  --servers[N].name string
+`
+	if buf.String() != expectUsage {
+		t.Fatalf("unexpected usage: %s", buf.String())
+	}
+}
@@ -1 +1,3 @@
 module github.com/cosiner/go-schema
+
+go 1.22
@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AfterDecoder is implemented by structures need to update fields after decoded, it's called before Validator.
+type AfterDecoder interface {
+	AfterDecode() error
+}
+
+// Validator is implemented by structures need cross field checks after decoded.
+type Validator interface {
+	Validate() error
+}
+
+// BeforeEncoder is implemented by structures need to prepare fields before encoded, it's called on a copy of the
+// encoding value, structures referenced by pointers on the way to the hooked structure are also copied.
+type BeforeEncoder interface {
+	BeforeEncode() error
+}
+
+var (
+	afterDecoderType  = reflect.TypeOf((*AfterDecoder)(nil)).Elem()
+	validatorType     = reflect.TypeOf((*Validator)(nil)).Elem()
+	beforeEncoderType = reflect.TypeOf((*BeforeEncoder)(nil)).Elem()
+)
+
+// structHook records the position of structure implements any hook interface, anonymous structures are not recorded
+// because their methods are promoted to the parent.
+type structHook struct {
+	Index []int
+	Path  string
+}
+
+func hasHooks(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(afterDecoderType) || pt.Implements(validatorType) || pt.Implements(beforeEncoderType)
+}
+
+// hookValue returns pointer of the structure, false is returned if it's not allocated.
+func (h *structHook) hookValue(refv reflect.Value) (interface{}, bool) {
+	v, ok := fieldByIndex(refv, h.Index, false)
+	if !ok {
+		return nil, false
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	return v.Addr().Interface(), true
+}
+
+// runDecodeHooks calls AfterDecode and Validate bottom-up, the first error is reported.
+func (d *Decoder) runDecodeHooks(refv reflect.Value, typInfo *structureInfo) DecodeErrors {
+	for i := len(typInfo.hooks) - 1; i >= 0; i-- {
+		hook := &typInfo.hooks[i]
+		v, ok := hook.hookValue(refv)
+		if !ok {
+			continue
+		}
+		if h, ok := v.(AfterDecoder); ok {
+			if err := h.AfterDecode(); err != nil {
+				return DecodeErrors{{Field: hook.Path, Err: err}}
+			}
+		}
+		if h, ok := v.(Validator); ok {
+			if err := h.Validate(); err != nil {
+				return DecodeErrors{{Field: hook.Path, Err: err}}
+			}
+		}
+	}
+	return nil
+}
+
+// copyPointers replaces pointers to structures along index with pointers to copies, so that hooks don't modify values
+// referenced by the caller.
+func (e *Encoder) copyPointers(v reflect.Value, index []int) {
+	for _, x := range index {
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		v = v.Field(x)
+		if v.Kind() != reflect.Ptr {
+			continue
+		}
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		v.Set(cp)
+	}
+}
+
+// runEncodeHooks calls BeforeEncode top-down, refv must be an addressable copy.
+func (e *Encoder) runEncodeHooks(refv reflect.Value, typInfo *structureInfo) error {
+	for i := range typInfo.hooks {
+		hook := &typInfo.hooks[i]
+		e.copyPointers(refv, hook.Index)
+		v, ok := hook.hookValue(refv)
+		if !ok {
+			continue
+		}
+		if h, ok := v.(BeforeEncoder); ok {
+			if err := h.BeforeEncode(); err != nil {
+				return fmt.Errorf("before encode hook failed: %s, %s", reflect.TypeOf(v).Elem().String(), err.Error())
+			}
+		}
+	}
+	return nil
+}
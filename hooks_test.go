@@ -0,0 +1,128 @@
+package schema_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+var hookCalls []string
+
+type HookRange struct {
+	Start int `schema:"query"`
+	End   int `schema:"query"`
+}
+
+func (r *HookRange) AfterDecode() error {
+	hookCalls = append(hookCalls, "range.AfterDecode")
+	if r.End == 0 {
+		r.End = r.Start
+	}
+	return nil
+}
+
+func (r *HookRange) Validate() error {
+	hookCalls = append(hookCalls, "range.Validate")
+	if r.Start > r.End {
+		return errors.New("start is greater than end")
+	}
+	return nil
+}
+
+type HookItem struct {
+	Name string `schema:"query"`
+}
+
+func (i *HookItem) Validate() error {
+	hookCalls = append(hookCalls, "item.Validate")
+	if i.Name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+type HookInner struct {
+	V string `schema:"query"`
+}
+
+func (i *HookInner) BeforeEncode() error {
+	i.V = "changed"
+	return nil
+}
+
+type HookQuery struct {
+	Keyword string `schema:"query"`
+	Range   HookRange
+	Items   []HookItem
+	Inner   *HookInner
+}
+
+func (q *HookQuery) Validate() error {
+	hookCalls = append(hookCalls, "query.Validate")
+	return nil
+}
+
+func (q *HookQuery) BeforeEncode() error {
+	q.Keyword = "encoded:" + q.Keyword
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	hookCalls = nil
+	var data HookQuery
+	err := d.Decode(Sources{
+		"query": url.Values{
+			"Range.Start":   []string{"1"},
+			"Items[0].Name": []string{"a"},
+		},
+	}, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Range.End != 1 {
+		t.Fatal("AfterDecode should be called")
+	}
+	expectCalls := []string{"item.Validate", "range.AfterDecode", "range.Validate", "query.Validate"}
+	if !reflect.DeepEqual(hookCalls, expectCalls) {
+		t.Fatalf("unexpected hook calls: %v", hookCalls)
+	}
+
+	hookCalls = nil
+	err = d.Decode(Sources{
+		"query": url.Values{
+			"Range.Start": []string{"2"},
+			"Range.End":   []string{"1"},
+		},
+	}, &data)
+	var fieldErr *schema.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "Range" || err.Error() != "invalid field values: Range: start is greater than end" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = d.Decode(Sources{"query": url.Values{"Items[0].Keyword": []string{"a"}}}, &HookQuery{})
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "Items[0]" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hookCalls = nil
+	err = d.Decode(Sources{"query": url.Values{"Range.Start": []string{"a"}, "Items[0].Name": []string{"a"}}}, &HookQuery{})
+	if err == nil || len(hookCalls) != 0 {
+		t.Fatal("hooks should be skipped if decode failed")
+	}
+
+	src := HookQuery{Keyword: "go", Inner: &HookInner{V: "orig"}}
+	dst := make(Sources)
+	err = e.Encode(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Keyword != "go" || dst["query"].Get("Keyword") != "encoded:go" {
+		t.Fatalf("unexpected encoded data: %+v", dst)
+	}
+	if src.Inner.V != "orig" || dst["query"].Get("Inner.V") != "changed" {
+		t.Fatalf("hooks should not modify encoding value: %+v, %+v", src.Inner, dst)
+	}
+}
@@ -0,0 +1,369 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// HTTPPart is the part of http request where values come from.
+type HTTPPart int
+
+const (
+	HTTPQuery HTTPPart = iota + 1
+	HTTPForm
+	HTTPPath
+	HTTPHeader
+	HTTPCookie
+	HTTPFile
+	HTTPJSON
+)
+
+// DefaultMaxMemory is the max memory used to parse multipart form, remaining parts are stored on disk.
+const DefaultMaxMemory = 32 << 20
+
+// DefaultHTTPParts returns the default mapping from source names to http request parts.
+func DefaultHTTPParts() map[string]HTTPPart {
+	return map[string]HTTPPart{
+		"query":  HTTPQuery,
+		"form":   HTTPForm,
+		"path":   HTTPPath,
+		"header": HTTPHeader,
+		"cookie": HTTPCookie,
+		"file":   HTTPFile,
+	}
+}
+
+// HTTPRequestSource is a DecoderSource for *http.Request, query and form are parsed at most once. Path values are
+// retrieved by http.Request.PathValue which is set by http.ServeMux. Files of multipart form is provided by FileSource.
+// JSON body is parsed by JSONSource, it shouldn't be used together with form in one request. HTTPJSON part isn't in
+// DefaultHTTPParts because HTTPRequestDestination doesn't support it, source name must be mapped to it explicitly.
+type HTTPRequestSource struct {
+	req   *http.Request
+	parts map[string]HTTPPart
+
+	query      url.Values
+	formParsed bool
+	json       *JSONSource
+	jsonParsed bool
+	err        error
+}
+
+var (
+	_ EnumerableSource = (*HTTPRequestSource)(nil)
+	_ FileSource       = (*HTTPRequestSource)(nil)
+	_ CanonicalSource  = (*HTTPRequestSource)(nil)
+	_ NullSource       = (*HTTPRequestSource)(nil)
+	_ ErrorSource      = (*HTTPRequestSource)(nil)
+)
+
+// NewHTTPRequestSource creates source for request, parts maps source names to request parts, DefaultHTTPParts is used
+// if it's nil.
+func NewHTTPRequestSource(req *http.Request, parts map[string]HTTPPart) *HTTPRequestSource {
+	if parts == nil {
+		parts = DefaultHTTPParts()
+	}
+	return &HTTPRequestSource{
+		req:   req,
+		parts: parts,
+	}
+}
+
+func (h *HTTPRequestSource) queryValues() url.Values {
+	if h.query == nil {
+		h.query = h.req.URL.Query()
+	}
+	return h.query
+}
+
+func (h *HTTPRequestSource) formValues() url.Values {
+	if !h.formParsed {
+		h.formParsed = true
+		mediaType, _, _ := mime.ParseMediaType(h.req.Header.Get("Content-Type"))
+		var err error
+		if mediaType == "multipart/form-data" {
+			err = h.req.ParseMultipartForm(DefaultMaxMemory)
+		} else {
+			err = h.req.ParseForm()
+		}
+		if err != nil && h.err == nil {
+			h.err = err
+		}
+	}
+	return h.req.PostForm
+}
+
+func (h *HTTPRequestSource) jsonSource(source string) *JSONSource {
+	if !h.jsonParsed {
+		h.jsonParsed = true
+		if h.req.Body != nil && h.req.Body != http.NoBody {
+			var err error
+			h.json, err = NewJSONSourceFromReader(source, h.req.Body)
+			if err != nil && h.err == nil {
+				h.err = err
+			}
+		}
+	}
+	return h.json
+}
+
+// Err returns the error occurred when parsing request form or JSON body, it's returned by Decoder.Decode.
+func (h *HTTPRequestSource) Err() error {
+	return h.err
+}
+
+func (h *HTTPRequestSource) Get(source, name string) []string {
+	switch h.parts[source] {
+	case HTTPQuery:
+		return h.queryValues()[name]
+	case HTTPForm:
+		return h.formValues()[name]
+	case HTTPPath:
+		v := h.req.PathValue(name)
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case HTTPHeader:
+		return h.req.Header.Values(name)
+	case HTTPCookie:
+		var vals []string
+		for _, c := range h.req.Cookies() {
+			if c.Name == name {
+				vals = append(vals, c.Value)
+			}
+		}
+		return vals
+	case HTTPJSON:
+		js := h.jsonSource(source)
+		if js == nil {
+			return nil
+		}
+		return js.Get(js.source, name)
+	default:
+		return nil
+	}
+}
+
+// IsNull reports null members of JSON body, values of other parts are never null.
+func (h *HTTPRequestSource) IsNull(source, name string) bool {
+	if h.parts[source] != HTTPJSON {
+		return false
+	}
+	js := h.jsonSource(source)
+	return js != nil && js.IsNull(js.source, name)
+}
+
+func (h *HTTPRequestSource) valuesKeys(vals map[string][]string, prefix string, ignoreCase bool) []string {
+	var keys []string
+	for key := range vals {
+		if strings.HasPrefix(key, prefix) || (ignoreCase && len(key) >= len(prefix) && strings.EqualFold(key[:len(prefix)], prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *HTTPRequestSource) Files(source, name string) []*multipart.FileHeader {
+	if h.parts[source] != HTTPFile {
+		return nil
+	}
+	h.formValues()
+	if h.req.MultipartForm == nil {
+		return nil
+	}
+	return h.req.MultipartForm.File[name]
+}
+
+// CanonicalName canonicalizes header names by textproto.CanonicalMIMEHeaderKey, names of other parts are unchanged.
+func (h *HTTPRequestSource) CanonicalName(source, name string) string {
+	if h.parts[source] == HTTPHeader {
+		return textproto.CanonicalMIMEHeaderKey(name)
+	}
+	return name
+}
+
+// Keys lists keys of source, path values is not enumerable.
+func (h *HTTPRequestSource) Keys(source, prefix string) []string {
+	switch h.parts[source] {
+	case HTTPQuery:
+		return h.valuesKeys(h.queryValues(), prefix, false)
+	case HTTPForm:
+		return h.valuesKeys(h.formValues(), prefix, false)
+	case HTTPHeader:
+		return h.valuesKeys(h.req.Header, prefix, true)
+	case HTTPFile:
+		h.formValues()
+		if h.req.MultipartForm == nil {
+			return nil
+		}
+		var keys []string
+		for key := range h.req.MultipartForm.File {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return keys
+	case HTTPCookie:
+		var keys []string
+		for _, c := range h.req.Cookies() {
+			if strings.HasPrefix(c.Name, prefix) && !hasString(keys, c.Name) {
+				keys = append(keys, c.Name)
+			}
+		}
+		sort.Strings(keys)
+		return keys
+	case HTTPJSON:
+		js := h.jsonSource(source)
+		if js == nil {
+			return nil
+		}
+		return js.Keys(js.source, prefix)
+	default:
+		return nil
+	}
+}
+
+// HTTPRequestDestination is an EncoderDestination to build *http.Request, path values are substituted into the url
+// pattern such as /users/{id}, and {name...} is allowed to contain slashes.
+type HTTPRequestDestination struct {
+	method  string
+	pattern string
+	parts   map[string]HTTPPart
+
+	query   url.Values
+	form    url.Values
+	path    map[string]string
+	header  http.Header
+	cookies []*http.Cookie
+}
+
+var _ EncoderDestination = (*HTTPRequestDestination)(nil)
+
+// NewHTTPRequestDestination creates destination for building request, parts maps source names to request parts,
+// DefaultHTTPParts is used if it's nil.
+func NewHTTPRequestDestination(method, urlPattern string, parts map[string]HTTPPart) *HTTPRequestDestination {
+	if parts == nil {
+		parts = DefaultHTTPParts()
+	}
+	return &HTTPRequestDestination{
+		method:  method,
+		pattern: urlPattern,
+		parts:   parts,
+		query:   make(url.Values),
+		form:    make(url.Values),
+		path:    make(map[string]string),
+		header:  make(http.Header),
+	}
+}
+
+func (h *HTTPRequestDestination) Set(source, name string, v []string) (bool, error) {
+	switch h.parts[source] {
+	case HTTPQuery:
+		h.query[name] = append(h.query[name], v...)
+	case HTTPForm:
+		h.form[name] = append(h.form[name], v...)
+	case HTTPPath:
+		if len(v) != 1 {
+			return false, fmt.Errorf("multiple path values is not allowed: %v", v)
+		}
+		h.path[name] = v[0]
+	case HTTPHeader:
+		for _, v := range v {
+			h.header.Add(name, v)
+		}
+	case HTTPCookie:
+		for _, v := range v {
+			h.cookies = append(h.cookies, &http.Cookie{Name: name, Value: v})
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func (h *HTTPRequestDestination) buildURL() (*url.URL, error) {
+	var (
+		buf     strings.Builder
+		pattern = h.pattern
+	)
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			buf.WriteString(pattern)
+			break
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid url pattern: %s", h.pattern)
+		}
+		end += start
+		buf.WriteString(pattern[:start])
+
+		name := pattern[start+1 : end]
+		isWildcard := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		val, has := h.path[name]
+		if !has {
+			return nil, fmt.Errorf("path value not found: %s", name)
+		}
+		if isWildcard {
+			segs := strings.Split(val, "/")
+			for i := range segs {
+				segs[i] = url.PathEscape(segs[i])
+			}
+			buf.WriteString(strings.Join(segs, "/"))
+		} else {
+			buf.WriteString(url.PathEscape(val))
+		}
+		pattern = pattern[end+1:]
+	}
+
+	u, err := url.Parse(buf.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(h.query) > 0 {
+		query := u.Query()
+		for name, vals := range h.query {
+			query[name] = append(query[name], vals...)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u, nil
+}
+
+// Request builds the request, form values is encoded as application/x-www-form-urlencoded body.
+func (h *HTTPRequestDestination) Request(ctx context.Context) (*http.Request, error) {
+	u, err := h.buildURL()
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	if len(h.form) > 0 {
+		req, err = http.NewRequestWithContext(ctx, h.method, u.String(), strings.NewReader(h.form.Encode()))
+	} else {
+		req, err = http.NewRequestWithContext(ctx, h.method, u.String(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for name, vals := range h.header {
+		req.Header[name] = append(req.Header[name], vals...)
+	}
+	if len(h.form) > 0 {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for _, c := range h.cookies {
+		req.AddCookie(c)
+	}
+	return req, nil
+}
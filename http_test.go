@@ -0,0 +1,226 @@
+package schema_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func newHTTPParser(t *testing.T) *schema.Parser {
+	p, err := schema.NewParser("schema", []string{"path", "query", "form", "header", "cookie", "file"}, func(v string) string { return v })
+	if err == nil {
+		err = p.RegisterTypes(schema.BuiltinTypes()...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestHTTPRequestSource(t *testing.T) {
+	d, err := schema.NewDecoder(newHTTPParser(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Request struct {
+		ID      int               `schema:"path" path:"id"`
+		Page    int               `schema:"query" query:"page"`
+		Tags    []string          `schema:"query" query:"tag"`
+		Name    string            `schema:"form" form:"name"`
+		Token   string            `schema:"header" header:"x-token"`
+		Meta    map[string]string `schema:"header" header:"X-Meta-"`
+		Session string            `schema:"cookie" cookie:"session"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/users/10?page=2&tag=a&tag=b", strings.NewReader("name=someone"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Token", "token")
+	req.Header.Set("X-Meta-Lang", "go")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "sid"})
+
+	var data Request
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		src := schema.NewHTTPRequestSource(r, nil)
+		err = d.Decode(src, &data)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectData := Request{
+		ID:      10,
+		Page:    2,
+		Tags:    []string{"a", "b"},
+		Name:    "someone",
+		Token:   "token",
+		Meta:    map[string]string{"Lang": "go"},
+		Session: "sid",
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=%zz"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err = d.Decode(schema.NewHTTPRequestSource(req, nil), &Request{})
+	if err == nil {
+		t.Fatal("malformed form should fail")
+	}
+}
+
+func TestHTTPHeaderCase(t *testing.T) {
+	d, err := schema.NewDecoder(newHTTPParser(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetStrict("header")
+
+	type Item struct {
+		Meta map[string]string `schema:"header" header:"x-meta-"`
+	}
+	type Request struct {
+		Token string            `schema:"header" header:"x-token"`
+		Meta  map[string]string `schema:"header" header:"x-meta-"`
+		Items []Item
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Token", "token")
+	req.Header.Set("X-Meta-Lang", "go")
+	req.Header.Set("Items[0].X-Meta-Env", "dev")
+
+	var data Request
+	err = d.Decode(schema.NewHTTPRequestSource(req, nil), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := Request{
+		Token: "token",
+		Meta:  map[string]string{"Lang": "go"},
+		Items: []Item{{Meta: map[string]string{"Env": "dev"}}},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+}
+
+func TestHTTPRequestDestination(t *testing.T) {
+	p := newHTTPParser(t)
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := schema.NewEncoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Request struct {
+		ID      string   `schema:"path" path:"id"`
+		File    string   `schema:"path" path:"file"`
+		Page    int      `schema:"query" query:"page"`
+		Tags    []string `schema:"query" query:"tag"`
+		Name    string   `schema:"form" form:"name"`
+		Token   string   `schema:"header" header:"X-Token"`
+		Session string   `schema:"cookie" cookie:"session"`
+	}
+	data := Request{
+		ID:      "a b",
+		File:    "dir/file.txt",
+		Page:    2,
+		Tags:    []string{"a", "b"},
+		Name:    "someone",
+		Token:   "token",
+		Session: "sid",
+	}
+	dst := schema.NewHTTPRequestDestination(http.MethodPost, "http://localhost/users/{id}/files/{file...}", nil)
+	err = e.Encode(data, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := dst.Request(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.EscapedPath() != "/users/a%20b/files/dir/file.txt" {
+		t.Fatalf("unexpected path: %s", req.URL.EscapedPath())
+	}
+
+	var decoded Request
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}/files/{file...}", func(w http.ResponseWriter, r *http.Request) {
+		err = d.Decode(schema.NewHTTPRequestSource(r, nil), &decoded)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, decoded) {
+		t.Fatalf("unexpected decode result: %+v", decoded)
+	}
+
+	dst = schema.NewHTTPRequestDestination(http.MethodGet, "/users/{id}", nil)
+	_, err = dst.Request(context.Background())
+	if err == nil {
+		t.Fatal("missing path value should fail")
+	}
+}
+
+func TestHTTPMultipartFiles(t *testing.T) {
+	d, err := schema.NewDecoder(newHTTPParser(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Upload struct {
+		Name   string                  `schema:"form" form:"name"`
+		Avatar *multipart.FileHeader   `schema:"file;required" file:"avatar"`
+		Docs   []*multipart.FileHeader `schema:"file" file:"docs"`
+		Extra  *multipart.FileHeader   `schema:"file" file:"extra"`
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("name", "someone")
+	for _, f := range []struct{ Field, Name, Content string }{
+		{"avatar", "avatar.png", "png"},
+		{"docs", "a.txt", "a"},
+		{"docs", "b.txt", "b"},
+	} {
+		w, err := mw.CreateFormFile(f.Field, f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(w, f.Content)
+	}
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var data Upload
+	src := schema.NewHTTPRequestSource(req, nil)
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != "someone" || data.Avatar == nil || data.Avatar.Filename != "avatar.png" || len(data.Docs) != 2 || data.Docs[1].Filename != "b.txt" || data.Extra != nil {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+	f, err := data.Docs[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	content, _ := io.ReadAll(f)
+	if string(content) != "a" {
+		t.Fatalf("unexpected file content: %s", content)
+	}
+}
@@ -0,0 +1,196 @@
+package schema
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// INISource is a DecoderSource for INI or properties file, keys under [section] are named as "section.key" which
+// matches the nested context of structure fields, repeated keys are values of slice fields. Lines started with ";" or
+// "#" are comments, keys and values can be double quoted.
+type INISource struct {
+	source string
+	values map[string][]string
+	keys   []string
+}
+
+var _ EnumerableSource = (*INISource)(nil)
+
+// NewINISource parses file content read from r as values of source.
+func NewINISource(source string, r io.Reader) (*INISource, error) {
+	s := INISource{
+		source: source,
+		values: make(map[string][]string),
+	}
+
+	var (
+		section string
+		lineno  int
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("invalid section at line %d: %s", lineno, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, err := s.splitLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s at line %d: %s", err.Error(), lineno, line)
+		}
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value at line %d: %s", lineno, value)
+			}
+			value = unquoted
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		if _, has := s.values[key]; !has {
+			s.keys = append(s.keys, key)
+		}
+		s.values[key] = append(s.values[key], value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(s.keys)
+	return &s, nil
+}
+
+// splitLine splits line into key and value by the first "=" or ":", double quoted key may contain them.
+func (s *INISource) splitLine(line string) (key, value string, err error) {
+	if line[0] == '"' {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid quoted key")
+		}
+		key, _ = strconv.Unquote(quoted)
+		line = strings.TrimSpace(line[len(quoted):])
+		if line == "" || (line[0] != '=' && line[0] != ':') {
+			return "", "", fmt.Errorf("invalid key value")
+		}
+		return key, strings.TrimSpace(line[1:]), nil
+	}
+	i := strings.IndexAny(line, "=:")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid key value")
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), nil
+}
+
+func (s *INISource) Get(source, name string) []string {
+	if source != s.source {
+		return nil
+	}
+	return s.values[name]
+}
+
+func (s *INISource) Keys(source, prefix string) []string {
+	if source != s.source {
+		return nil
+	}
+	var keys []string
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// INIDestination is an EncoderDestination writes INI file, names are split into section and key by the last dot.
+type INIDestination struct {
+	source   string
+	sections map[string]map[string][]string
+}
+
+var _ EncoderDestination = (*INIDestination)(nil)
+
+func NewINIDestination(source string) *INIDestination {
+	return &INIDestination{
+		source:   source,
+		sections: make(map[string]map[string][]string),
+	}
+}
+
+func (d *INIDestination) Set(source, name string, v []string) (bool, error) {
+	if source != d.source {
+		return false, nil
+	}
+	var section, key string
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		section, key = name[:i], name[i+1:]
+	} else {
+		key = name
+	}
+	keys, has := d.sections[section]
+	if !has {
+		keys = make(map[string][]string)
+		d.sections[section] = keys
+	}
+	keys[key] = append(keys[key], v...)
+	return true, nil
+}
+
+func (d *INIDestination) quote(v string) string {
+	if v != strings.TrimSpace(v) || strings.ContainsAny(v, ";#\"\r\n") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// quoteKey quotes keys contain separators so that map keys such as "addrs[host:port]" are read back as it is.
+func (d *INIDestination) quoteKey(key string) string {
+	if key != strings.TrimSpace(key) || strings.HasPrefix(key, "[") || strings.ContainsAny(key, "=:;#\"\r\n") {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// WriteTo writes keys without section first, then sections, both sections and keys are sorted.
+func (d *INIDestination) WriteTo(w io.Writer) (int64, error) {
+	sections := make([]string, 0, len(d.sections))
+	for section := range d.sections {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	var buf strings.Builder
+	for _, section := range sections {
+		if section != "" {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("[" + section + "]\n")
+		}
+
+		values := d.sections[section]
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, v := range values[key] {
+				buf.WriteString(d.quoteKey(key) + " = " + d.quote(v) + "\n")
+			}
+		}
+	}
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
@@ -0,0 +1,140 @@
+package schema_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func TestINISource(t *testing.T) {
+	type DB struct {
+		Host  string   `schema:"file"`
+		Port  int      `schema:"file"`
+		Hosts []string `schema:"file"`
+	}
+	type Server struct {
+		Name string `schema:"file"`
+	}
+	type Config struct {
+		Name    string            `schema:"file"`
+		Motto   string            `schema:"file"`
+		Labels  map[string]string `schema:"file"`
+		Primary DB
+		Replica DB
+		Servers []Server
+	}
+	content := `
+; comment
+name = app
+motto = " hello; world "
+labels[env]: dev
+
+[primary]
+host = localhost
+port = 5432
+# repeated keys
+hosts = a
+hosts = b
+
+[replica]
+host = remote
+
+[servers[0]]
+name = x
+`
+	expectData := Config{
+		Name:    "app",
+		Motto:   " hello; world ",
+		Labels:  map[string]string{"env": "dev"},
+		Primary: DB{Host: "localhost", Port: 5432, Hosts: []string{"a", "b"}},
+		Replica: DB{Host: "remote"},
+		Servers: []Server{{Name: "x"}},
+	}
+
+	p := newConfigParser(t)
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := schema.NewINISource("file", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data Config
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	e, err := schema.NewEncoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := schema.NewINIDestination("file")
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_, err = dst.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFile := `labels[env] = dev
+motto = " hello; world "
+name = app
+
+[primary]
+host = localhost
+hosts = a
+hosts = b
+port = 5432
+
+[replica]
+host = remote
+port = 0
+
+[servers[0]]
+name = x
+`
+	if buf.String() != expectFile {
+		t.Fatalf("unexpected ini file: %s", buf.String())
+	}
+
+	dst = schema.NewINIDestination("file")
+	err = e.Encode(Config{Labels: map[string]string{"host:port": "a=b"}}, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	_, err = dst.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `"labels[host:port]" = a=b`+"\n") {
+		t.Fatalf("unexpected ini file: %s", buf.String())
+	}
+	src, err = schema.NewINISource("file", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = Config{}
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.Labels, map[string]string{"host:port": "a=b"}) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	_, err = schema.NewINISource("file", strings.NewReader("[section"))
+	if err == nil {
+		t.Fatal("invalid section should fail")
+	}
+}
@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSource is a DecoderSource for JSON object, the document is parsed once and flattened to dotted names such as
+// "address.city". Arrays of scalars are values of slice fields, elements of other arrays are named as items[0].name.
+// Null members are reported by NullSource and not listed by Keys.
+type JSONSource struct {
+	source string
+	values map[string][]string
+	nulls  map[string]bool
+	keys   []string
+}
+
+var (
+	_ EnumerableSource = (*JSONSource)(nil)
+	_ NullSource       = (*JSONSource)(nil)
+)
+
+// NewJSONSource parses data as values of source.
+func NewJSONSource(source string, data []byte) (*JSONSource, error) {
+	return NewJSONSourceFromReader(source, bytes.NewReader(data))
+}
+
+// NewJSONSourceFromReader parses JSON object read from r as values of source.
+func NewJSONSourceFromReader(source string, r io.Reader) (*JSONSource, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc interface{}
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json document: %s", err.Error())
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("json document isn't object")
+	}
+
+	s := JSONSource{
+		source: source,
+		values: make(map[string][]string),
+		nulls:  make(map[string]bool),
+	}
+	s.flattenObject("", obj)
+	s.keys = make([]string, 0, len(s.values))
+	for key := range s.values {
+		s.keys = append(s.keys, key)
+	}
+	sort.Strings(s.keys)
+	return &s, nil
+}
+
+func (s *JSONSource) scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	default:
+		return "", false
+	}
+}
+
+func (s *JSONSource) flattenObject(prefix string, obj map[string]interface{}) {
+	for key, v := range obj {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if v == nil {
+			s.nulls[key] = true
+			continue
+		}
+		s.flatten(key, v)
+	}
+}
+
+func (s *JSONSource) flatten(name string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		s.flattenObject(name, v)
+	case []interface{}:
+		isScalars := true
+		for _, elem := range v {
+			if _, ok := s.scalarString(elem); !ok && elem != nil {
+				isScalars = false
+				break
+			}
+		}
+		if !isScalars {
+			for i, elem := range v {
+				s.flatten(name+"["+strconv.Itoa(i)+"]", elem)
+			}
+			return
+		}
+		for _, elem := range v {
+			if str, ok := s.scalarString(elem); ok {
+				s.values[name] = append(s.values[name], str)
+			}
+		}
+	default:
+		str, _ := s.scalarString(v)
+		s.values[name] = append(s.values[name], str)
+	}
+}
+
+func (s *JSONSource) Get(source, name string) []string {
+	if source != s.source {
+		return nil
+	}
+	return s.values[name]
+}
+
+func (s *JSONSource) IsNull(source, name string) bool {
+	return source == s.source && s.nulls[name]
+}
+
+func (s *JSONSource) Keys(source, prefix string) []string {
+	if source != s.source {
+		return nil
+	}
+	var keys []string
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,86 @@
+package schema_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func TestJSONSource(t *testing.T) {
+	type Address struct {
+		City string `schema:"body"`
+		Zip  *int   `schema:"body"`
+	}
+	type Item struct {
+		Name  string `schema:"body"`
+		Count int    `schema:"body"`
+	}
+	type TestDecoderStruct struct {
+		Name    string            `schema:"body"`
+		Age     uint8             `schema:"body"`
+		Admin   bool              `schema:"body"`
+		Tags    []string          `schema:"body"`
+		ID      int64             `schema:"body"`
+		Address Address           `schema:"body"`
+		Items   []Item            `schema:"body"`
+		Labels  map[string]string `schema:"body"`
+		Page    int               `schema:"query"`
+	}
+	doc := `{
+		"Name": "someone",
+		"Age": 18,
+		"Admin": true,
+		"Tags": ["a", "b"],
+		"ID": 9007199254740993,
+		"Address": {"City": "Paris", "Zip": null},
+		"Items": [{"Name": "x", "Count": 1}, {"Name": "y", "Count": 2}],
+		"Labels": {"env": "dev"}
+	}`
+	expectData := TestDecoderStruct{
+		Name:    "someone",
+		Age:     18,
+		Admin:   true,
+		Tags:    []string{"a", "b"},
+		ID:      9007199254740993,
+		Address: Address{City: "Paris"},
+		Items:   []Item{{Name: "x", Count: 1}, {Name: "y", Count: 2}},
+		Labels:  map[string]string{"env": "dev"},
+	}
+
+	src, err := schema.NewJSONSource("body", []byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data TestDecoderStruct
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	_, err = schema.NewJSONSource("body", []byte(`[1, 2]`))
+	if err == nil {
+		t.Fatal("non-object document should fail")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/?Page=2", strings.NewReader(doc))
+	httpSrc := schema.NewHTTPRequestSource(req, map[string]schema.HTTPPart{
+		"body":  schema.HTTPJSON,
+		"query": schema.HTTPQuery,
+	})
+	data = TestDecoderStruct{}
+	err = d.Decode(httpSrc, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData.Page = 2
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+}
@@ -0,0 +1,314 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaType is an optional interface of Type to contribute JSON Schema of decoded values, such as
+// {"type": "string", "format": "duration"}.
+type JSONSchemaType interface {
+	JSONSchema() map[string]interface{}
+}
+
+// JSONSchemaVersion is the $schema of generated documents.
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+func jsonSchemaInteger(min, max json.Number, format string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": min, "maximum": max, "format": format}
+}
+
+func jsonSchemaIntBounds(bits int, format string) map[string]interface{} {
+	return jsonSchemaInteger(
+		json.Number(strconv.FormatInt(math.MinInt64>>(64-bits), 10)),
+		json.Number(strconv.FormatInt(math.MaxInt64>>(64-bits), 10)),
+		format,
+	)
+}
+
+func jsonSchemaUintBounds(bits int, format string) map[string]interface{} {
+	return jsonSchemaInteger("0", json.Number(strconv.FormatUint(math.MaxUint64>>(64-bits), 10)), format)
+}
+
+// jsonSchemaOfType returns JSON Schema of values decoded by enc, types implement encoding.TextUnmarshaler and custom
+// types without JSONSchemaType are strings.
+func jsonSchemaOfType(enc Type) map[string]interface{} {
+	switch enc := enc.(type) {
+	case JSONSchemaType:
+		// the map may be shared by the Type, it's copied before decorated by fields.
+		s := make(map[string]interface{})
+		for k, v := range enc.JSONSchema() {
+			s[k] = v
+		}
+		return s
+	case convertType:
+		return jsonSchemaOfType(enc.enc)
+	case boolType:
+		return map[string]interface{}{"type": "boolean"}
+	case intType:
+		return jsonSchemaIntBounds(strconv.IntSize, "int"+strconv.Itoa(strconv.IntSize))
+	case int8Type:
+		return jsonSchemaIntBounds(8, "int8")
+	case int16Type:
+		return jsonSchemaIntBounds(16, "int16")
+	case int32Type:
+		return jsonSchemaIntBounds(32, "int32")
+	case int64Type:
+		return jsonSchemaIntBounds(64, "int64")
+	case uintType:
+		return jsonSchemaUintBounds(strconv.IntSize, "uint"+strconv.Itoa(strconv.IntSize))
+	case uint8Type:
+		return jsonSchemaUintBounds(8, "uint8")
+	case uint16Type:
+		return jsonSchemaUintBounds(16, "uint16")
+	case uint32Type:
+		return jsonSchemaUintBounds(32, "uint32")
+	case uint64Type:
+		return jsonSchemaUintBounds(64, "uint64")
+	case float32Type:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case float64Type:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// jsonSchemaValue decodes default or option value to JSON value by enc, the string itself is returned if failed.
+func jsonSchemaValue(enc Type, s string) interface{} {
+	typ, _ := jsonSchemaOfType(enc)["type"].(string)
+	switch typ {
+	case "integer", "number", "boolean":
+	default:
+		return s
+	}
+	val, err := enc.Decode(s)
+	if err != nil {
+		return s
+	}
+	v := reflect.ValueOf(val)
+	switch {
+	case v.CanInt():
+		return v.Int()
+	case v.CanUint():
+		return v.Uint()
+	case v.CanFloat():
+		if n, ok := jsonSchemaNumber(v.Float(), v.Type().Bits()); ok {
+			return n
+		}
+	case v.Kind() == reflect.Bool:
+		return v.Bool()
+	}
+	return s
+}
+
+// jsonSchemaNumber formats n as JSON number, infinity and NaN are not allowed.
+func jsonSchemaNumber(n float64, bitSize int) (json.Number, bool) {
+	if math.IsInf(n, 0) || math.IsNaN(n) {
+		return "", false
+	}
+	return json.Number(strconv.FormatFloat(n, 'f', -1, bitSize)), true
+}
+
+func jsonSchemaRule(s map[string]interface{}, field *fieldInfo, rule *validateRule) {
+	elem := s
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		elem = items
+	} else if props, ok := s["additionalProperties"].(map[string]interface{}); ok {
+		elem = props
+	}
+	switch rule.Name {
+	case "min", "max", "len", "nonempty":
+		param, ok := jsonSchemaNumber(rule.Num, 64)
+		if rule.Name == "nonempty" {
+			param, ok = "1", true
+		}
+		if !ok {
+			return
+		}
+		var min, max string
+		switch s["type"] {
+		case "array":
+			min, max = "minItems", "maxItems"
+		case "object":
+			min, max = "minProperties", "maxProperties"
+		case "string":
+			min, max = "minLength", "maxLength"
+		default:
+			min, max = "minimum", "maximum"
+			if rule.Name == "len" || rule.Name == "nonempty" {
+				return
+			}
+		}
+		switch rule.Name {
+		case "min", "nonempty":
+			s[min] = param
+		case "max":
+			s[max] = param
+		case "len":
+			s[min], s[max] = param, param
+		}
+	case "oneof":
+		var enum []interface{}
+		for _, opt := range strings.Split(rule.Param, "|") {
+			enum = append(enum, jsonSchemaValue(field.Encoding, opt))
+		}
+		elem["enum"] = enum
+	case "regexp":
+		elem["pattern"] = rule.Param
+	case "email":
+		elem["format"] = "email"
+	case "url":
+		elem["format"] = "uri"
+	case "uuid":
+		elem["format"] = "uuid"
+	}
+}
+
+func jsonSchemaOfField(field *fieldInfo) map[string]interface{} {
+	s := jsonSchemaOfType(field.Encoding)
+	if field.IsSlice {
+		s = map[string]interface{}{"type": "array", "items": s}
+		if field.IsArray {
+			l := json.Number(strconv.Itoa(field.ValueType.Len()))
+			s["minItems"], s["maxItems"] = l, l
+		}
+	}
+	if field.IsMap {
+		s = map[string]interface{}{"type": "object", "additionalProperties": s}
+	}
+	if field.HasDefault {
+		if field.IsSlice {
+			def := []interface{}{}
+			vals := []string{field.Default}
+			if field.Sep != "" {
+				vals = strings.Split(field.Default, field.Sep)
+			}
+			for _, v := range vals {
+				def = append(def, jsonSchemaValue(field.Encoding, v))
+			}
+			s["default"] = def
+		} else {
+			s["default"] = jsonSchemaValue(field.Encoding, field.Default)
+		}
+	}
+	for i := range field.Rules {
+		jsonSchemaRule(s, field, &field.Rules[i])
+	}
+	return s
+}
+
+func newJSONSchemaObject() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+// jsonSchemaParent returns the object contains the last segment of dotted name, nested objects are created if not
+// exist.
+func jsonSchemaParent(obj map[string]interface{}, name string) (map[string]interface{}, string, error) {
+	segs := strings.Split(name, ".")
+	for _, seg := range segs[:len(segs)-1] {
+		props := obj["properties"].(map[string]interface{})
+		child, has := props[seg]
+		if !has {
+			child = newJSONSchemaObject()
+			props[seg] = child
+		}
+		childObj, ok := child.(map[string]interface{})
+		if !ok || childObj["properties"] == nil {
+			return nil, "", fmt.Errorf("conflicted name: %s", name)
+		}
+		obj = childObj
+	}
+	return obj, segs[len(segs)-1], nil
+}
+
+func jsonSchemaAddProperty(obj map[string]interface{}, name string, prop map[string]interface{}, required bool) error {
+	parent, key, err := jsonSchemaParent(obj, name)
+	if err != nil {
+		return err
+	}
+	props := parent["properties"].(map[string]interface{})
+	if _, has := props[key]; has {
+		return fmt.Errorf("conflicted name: %s", name)
+	}
+	props[key] = prop
+	if required {
+		req, _ := parent["required"].([]string)
+		parent["required"] = append(req, key)
+	}
+	return nil
+}
+
+func jsonSchemaOfStructure(typInfo *structureInfo, source string) (map[string]interface{}, error) {
+	obj := newJSONSchemaObject()
+	for i := range typInfo.fields {
+		field := &typInfo.fields[i]
+		if field.IsFile {
+			continue
+		}
+		for _, src := range field.Sources {
+			if src.Source != source {
+				continue
+			}
+			prop := jsonSchemaOfField(field)
+			name := src.Name
+			if field.IsMap && name != "" && isMapKeySeparator(name[len(name)-1]) {
+				// entries named as prefix+key are properties matched by pattern in the parent object.
+				parent, key, err := jsonSchemaParent(obj, name)
+				if err != nil {
+					return nil, err
+				}
+				patterns, _ := parent["patternProperties"].(map[string]interface{})
+				if patterns == nil {
+					patterns = make(map[string]interface{})
+					parent["patternProperties"] = patterns
+				}
+				patterns["^"+regexp.QuoteMeta(key)] = prop["additionalProperties"]
+				break
+			}
+			// other sources may supply the value, only fields from this source are required.
+			required := field.Required && !field.HasDefault && len(field.Sources) == 1
+			err := jsonSchemaAddProperty(obj, name, prop, required)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	for i := range typInfo.structSlices {
+		slice := &typInfo.structSlices[i]
+		elem, err := jsonSchemaOfStructure(slice.Elem, source)
+		if err != nil {
+			return nil, err
+		}
+		if len(elem["properties"].(map[string]interface{})) == 0 {
+			continue
+		}
+		err = jsonSchemaAddProperty(obj, slice.Name, map[string]interface{}{"type": "array", "items": elem}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// JSONSchema generates JSON Schema document of fields from source in structure t, such as config files bound by
+// INISource or JSONSource. Dotted names are nested objects, slices are arrays, and maps are objects, integers are
+// bounded by the bit width. Required fields without default value are required properties if they are only bound to
+// source. Values of custom types are strings unless the Type implements JSONSchemaType.
+func JSONSchema(p *Parser, t reflect.Type, source string) (map[string]interface{}, error) {
+	typInfo, err := p.parseStructure(t)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonSchemaOfStructure(typInfo, source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type schema: %s, %s", t.String(), err.Error())
+	}
+	doc["$schema"] = JSONSchemaVersion
+	return doc, nil
+}
@@ -0,0 +1,78 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cosiner/go-schema"
+)
+
+type durationType struct{}
+
+func (durationType) DataType() interface{} { return time.Duration(0) }
+
+func (durationType) Decode(s string) (interface{}, error) { return time.ParseDuration(s) }
+
+func (durationType) Encode(v interface{}) (string, error) { return v.(time.Duration).String(), nil }
+
+var durationSchema = map[string]interface{}{"type": "string", "format": "duration"}
+
+func (durationType) JSONSchema() map[string]interface{} { return durationSchema }
+
+func TestJSONSchema(t *testing.T) {
+	type Server struct {
+		Host string `schema:"file"`
+	}
+	type DB struct {
+		Host     string        `schema:"file;required" validate:"nonempty"`
+		Port     uint16        `schema:"file;default=5432"`
+		MaxConns int8          `schema:"file" validate:"min=1"`
+		Timeout  time.Duration `schema:"file"`
+		Retry    time.Duration `schema:"file" validate:"oneof=1s|2s"`
+		Workers  int           `schema:"file;default=+5" validate:"max=1e2"`
+		Scale    float32       `schema:"file;default=0.1"`
+	}
+	type Config struct {
+		Name    string            `schema:"file,env;required"`
+		Ratio   float64           `schema:"file;default=0.5"`
+		Debug   bool              `schema:"env"`
+		Tags    []string          `schema:"file;default=a,b;sep=comma" validate:"oneof=a|b|c"`
+		Labels  map[string]string `schema:"file"`
+		DB      DB
+		Servers []Server
+	}
+	p := newConfigParser(t)
+	p.SetValidateTag("validate")
+	if err := p.RegisterTypes(durationType{}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := schema.JSONSchema(p, reflect.TypeOf(Config{}), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"$schema":"https://json-schema.org/draft/2020-12/schema","properties":{` +
+		`"dB":{"properties":{` +
+		`"host":{"minLength":1,"type":"string"},` +
+		`"maxConns":{"format":"int8","maximum":127,"minimum":1,"type":"integer"},` +
+		`"port":{"default":5432,"format":"uint16","maximum":65535,"minimum":0,"type":"integer"},` +
+		`"retry":{"enum":["1s","2s"],"format":"duration","type":"string"},` +
+		`"scale":{"default":0.1,"format":"float","type":"number"},` +
+		`"timeout":{"format":"duration","type":"string"},` +
+		`"workers":{"default":5,"format":"int64","maximum":100,"minimum":-9223372036854775808,"type":"integer"}},` +
+		`"required":["host"],"type":"object"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"type":"string"},` +
+		`"ratio":{"default":0.5,"format":"double","type":"number"},` +
+		`"servers":{"items":{"properties":{"host":{"type":"string"}},"type":"object"},"type":"array"},` +
+		`"tags":{"default":["a","b"],"items":{"enum":["a","b","c"],"type":"string"},"type":"array"}},` +
+		`"type":"object"}`
+	if string(got) != expect {
+		t.Fatalf("unexpected json schema: %s", got)
+	}
+}
@@ -0,0 +1,258 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// OpenAPISchema is the OpenAPI 3 schema object of parameter.
+type OpenAPISchema struct {
+	Type                 string         `json:"type,omitempty"`
+	Format               string         `json:"format,omitempty"`
+	Items                *OpenAPISchema `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema `json:"additionalProperties,omitempty"`
+	Default              interface{}    `json:"default,omitempty"`
+	Enum                 []interface{}  `json:"enum,omitempty"`
+	Minimum              *float64       `json:"minimum,omitempty"`
+	Maximum              *float64       `json:"maximum,omitempty"`
+	MinLength            *int           `json:"minLength,omitempty"`
+	MaxLength            *int           `json:"maxLength,omitempty"`
+	MinItems             *int           `json:"minItems,omitempty"`
+	MaxItems             *int           `json:"maxItems,omitempty"`
+	MinProperties        *int           `json:"minProperties,omitempty"`
+	MaxProperties        *int           `json:"maxProperties,omitempty"`
+	Pattern              string         `json:"pattern,omitempty"`
+}
+
+// OpenAPIParameter is the OpenAPI 3 parameter object.
+type OpenAPIParameter struct {
+	Name            string         `json:"name"`
+	In              string         `json:"in"`
+	Description     string         `json:"description,omitempty"`
+	Required        bool           `json:"required,omitempty"`
+	AllowEmptyValue bool           `json:"allowEmptyValue,omitempty"`
+	Style           string         `json:"style,omitempty"`
+	Explode         *bool          `json:"explode,omitempty"`
+	Schema          *OpenAPISchema `json:"schema"`
+}
+
+var openAPIIn = map[HTTPPart]string{
+	HTTPQuery:  "query",
+	HTTPPath:   "path",
+	HTTPHeader: "header",
+	HTTPCookie: "cookie",
+}
+
+// openAPIType returns schema of values decoded by enc, type and format are taken from JSON Schema of the Type, and
+// integers are int32 or int64 by bounds, unsigned integers have minimum 0.
+func openAPIType(enc Type) *OpenAPISchema {
+	js := jsonSchemaOfType(enc)
+	var s OpenAPISchema
+	s.Type, _ = js["type"].(string)
+	s.Format, _ = js["format"].(string)
+	if s.Type != "integer" {
+		return &s
+	}
+	minNum, _ := js["minimum"].(json.Number)
+	maxNum, _ := js["maximum"].(json.Number)
+	min, minErr := minNum.Float64()
+	max, maxErr := maxNum.Float64()
+	if minErr != nil || maxErr != nil {
+		return &s
+	}
+	if min >= math.MinInt32 && max <= math.MaxInt32 {
+		s.Format = "int32"
+	} else {
+		s.Format = "int64"
+	}
+	if min == 0 {
+		s.Minimum = &min
+	}
+	return &s
+}
+
+func openAPIRule(s *OpenAPISchema, field *fieldInfo, rule *validateRule) {
+	n := rule.Num
+	l := int(n)
+	switch rule.Name {
+	case "min", "max", "len", "nonempty":
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return
+		}
+		if rule.Name == "nonempty" {
+			l = 1
+		}
+		var min, max **int
+		switch s.Type {
+		case "array":
+			min, max = &s.MinItems, &s.MaxItems
+		case "object":
+			min, max = &s.MinProperties, &s.MaxProperties
+		case "string":
+			min, max = &s.MinLength, &s.MaxLength
+		default:
+			switch rule.Name {
+			case "min":
+				s.Minimum = &n
+			case "max":
+				s.Maximum = &n
+			}
+			return
+		}
+		switch rule.Name {
+		case "min", "nonempty":
+			*min = &l
+		case "max":
+			*max = &l
+		case "len":
+			*min, *max = &l, &l
+		}
+	case "oneof", "regexp", "email", "url", "uuid":
+		elem := s
+		if s.Items != nil {
+			elem = s.Items
+		} else if s.AdditionalProperties != nil {
+			elem = s.AdditionalProperties
+		}
+		switch rule.Name {
+		case "oneof":
+			for _, opt := range strings.Split(rule.Param, "|") {
+				elem.Enum = append(elem.Enum, jsonSchemaValue(field.Encoding, opt))
+			}
+		case "regexp":
+			elem.Pattern = rule.Param
+		case "email":
+			elem.Format = "email"
+		case "url":
+			elem.Format = "uri"
+		case "uuid":
+			elem.Format = "uuid"
+		}
+	}
+}
+
+func openAPIFieldSchema(field *fieldInfo) *OpenAPISchema {
+	var s *OpenAPISchema
+	if field.IsMap {
+		s = &OpenAPISchema{Type: "object", AdditionalProperties: openAPIType(field.Encoding)}
+	} else if field.IsSlice {
+		s = &OpenAPISchema{Type: "array", Items: openAPIType(field.Encoding)}
+		if field.IsArray {
+			l := field.ValueType.Len()
+			s.MinItems, s.MaxItems = &l, &l
+		}
+		if field.HasDefault {
+			vals := []string{field.Default}
+			if field.Sep != "" {
+				vals = strings.Split(field.Default, field.Sep)
+			}
+			var def []interface{}
+			for _, v := range vals {
+				def = append(def, jsonSchemaValue(field.Encoding, v))
+			}
+			s.Default = def
+		}
+	} else {
+		s = openAPIType(field.Encoding)
+		if field.HasDefault {
+			s.Default = jsonSchemaValue(field.Encoding, field.Default)
+		}
+	}
+	for i := range field.Rules {
+		openAPIRule(s, field, &field.Rules[i])
+	}
+	return s
+}
+
+// openAPIStyle returns style and explode of slice and map parameters. Values of slice without separator are repeated
+// keys in query and cookie, comma, space and pipe separated values are form, spaceDelimited and pipeDelimited in query.
+// Header and path values are only simple with comma separator because repeated header lines are not described by
+// OpenAPI. Maps in query are deepObject.
+func openAPIStyle(field *fieldInfo, in string) (style string, explode bool, err error) {
+	switch {
+	case in == "query" && field.IsMap:
+		return "deepObject", true, nil
+	case in == "query" || in == "cookie":
+		switch field.Sep {
+		case "":
+			return "form", true, nil
+		case ",":
+			return "form", false, nil
+		}
+		if in == "query" {
+			switch field.Sep {
+			case " ":
+				return "spaceDelimited", false, nil
+			case "|":
+				return "pipeDelimited", false, nil
+			}
+		}
+	case field.Sep == ",":
+		return "simple", false, nil
+	}
+	return "", false, fmt.Errorf("separator %q of %s parameter is not supported", field.Sep, in)
+}
+
+// OpenAPIParameters generates OpenAPI 3 parameter objects for fields of structure t, parts maps source names to request
+// parts, DefaultHTTPParts is used if it's nil. Only fields from query, path, header and cookie are generated, maps are
+// only supported in query, and structure slices are skipped. Path parameters are always required, other parameters
+// are required if the field is required and has only one source in parameters. Value types are derived from the
+// JSON Schema of field Type, and error is reported for slices whose separator can't be described by OpenAPI style,
+// such as headers without comma separator. descTag is the tag name of field description.
+func OpenAPIParameters(p *Parser, t reflect.Type, parts map[string]HTTPPart, descTag string) ([]OpenAPIParameter, error) {
+	if parts == nil {
+		parts = DefaultHTTPParts()
+	}
+	typInfo, err := p.parseStructure(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []OpenAPIParameter
+	for i := range typInfo.fields {
+		field := &typInfo.fields[i]
+		if field.IsFile {
+			continue
+		}
+		var sources []fieldSource
+		for _, src := range field.Sources {
+			in, has := openAPIIn[parts[src.Source]]
+			if !has || (field.IsMap && in != "query") {
+				continue
+			}
+			sources = append(sources, src)
+		}
+		for _, src := range sources {
+			in := openAPIIn[parts[src.Source]]
+			param := OpenAPIParameter{
+				Name:     src.Name,
+				In:       in,
+				Required: in == "path" || (field.Required && len(sources) == 1),
+				Schema:   openAPIFieldSchema(field),
+			}
+			if descTag != "" {
+				param.Description = field.Field.Tag.Get(descTag)
+			}
+			if field.IsOptional && in == "query" {
+				param.AllowEmptyValue = true
+			}
+			if field.IsSlice || field.IsMap {
+				style, explode, err := openAPIStyle(field, in)
+				if err != nil {
+					return nil, fmt.Errorf("invalid parameter: %s in %s, %s", param.Name, param.In, err.Error())
+				}
+				param.Style, param.Explode = style, &explode
+			}
+			for _, p := range params {
+				if p.Name == param.Name && p.In == param.In {
+					return nil, fmt.Errorf("duplicated parameter: %s in %s", p.Name, p.In)
+				}
+			}
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
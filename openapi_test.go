@@ -0,0 +1,72 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cosiner/go-schema"
+)
+
+func TestOpenAPIParameters(t *testing.T) {
+	type TestRequest struct {
+		ID      int64                   `schema:"path" desc:"user id"`
+		Status  string                  `schema:"query;default=on" validate:"oneof=on|off"`
+		Page    int32                   `schema:"query" validate:"min=1;max=100"`
+		Size    uint32                  `schema:"query"`
+		Limit   int                     `schema:"query;default=+5"`
+		Tags    []string                `schema:"query;sep=comma" validate:"max=3"`
+		IDs     []int                   `schema:"query"`
+		Since   time.Time               `schema:"query;required"`
+		Token   string                  `schema:"header,cookie;required"`
+		Langs   []string                `schema:"header;sep=comma"`
+		Meta    map[string]string       `schema:"query,header" header:"X-Meta-"`
+		Note    schema.Optional[string] `schema:"query"`
+		Filter  map[string]int          `schema:"query" validate:"nonempty;max=5;oneof=1|2"`
+		Content string                  `schema:"form"`
+	}
+	p := newHTTPParser(t)
+	p.SetValidateTag("validate")
+	params, err := schema.OpenAPIParameters(p, reflect.TypeOf(TestRequest{}), nil, "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `[` +
+		`{"name":"ID","in":"path","description":"user id","required":true,"schema":{"type":"integer","format":"int64"}},` +
+		`{"name":"Status","in":"query","schema":{"type":"string","default":"on","enum":["on","off"]}},` +
+		`{"name":"Page","in":"query","schema":{"type":"integer","format":"int32","minimum":1,"maximum":100}},` +
+		`{"name":"Size","in":"query","schema":{"type":"integer","format":"int64","minimum":0}},` +
+		`{"name":"Limit","in":"query","schema":{"type":"integer","format":"int64","default":5}},` +
+		`{"name":"Tags","in":"query","style":"form","explode":false,"schema":{"type":"array","items":{"type":"string"},"maxItems":3}},` +
+		`{"name":"IDs","in":"query","style":"form","explode":true,"schema":{"type":"array","items":{"type":"integer","format":"int64"}}},` +
+		`{"name":"Since","in":"query","required":true,"schema":{"type":"string","format":"date-time"}},` +
+		`{"name":"Token","in":"header","schema":{"type":"string"}},` +
+		`{"name":"Token","in":"cookie","schema":{"type":"string"}},` +
+		`{"name":"Langs","in":"header","style":"simple","explode":false,"schema":{"type":"array","items":{"type":"string"}}},` +
+		`{"name":"Meta","in":"query","style":"deepObject","explode":true,"schema":{"type":"object","additionalProperties":{"type":"string"}}},` +
+		`{"name":"Note","in":"query","allowEmptyValue":true,"schema":{"type":"string"}},` +
+		`{"name":"Filter","in":"query","style":"deepObject","explode":true,"schema":{"type":"object","additionalProperties":{"type":"integer","format":"int64","enum":[1,2]},"minProperties":1,"maxProperties":5}}` +
+		`]`
+	if string(got) != expect {
+		t.Fatalf("unexpected parameters: %s", got)
+	}
+
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(struct {
+			Langs []string `schema:"header"`
+		}{}),
+		reflect.TypeOf(struct {
+			Tags []string `schema:"query;sep=semicolon"`
+		}{}),
+	} {
+		_, err = schema.OpenAPIParameters(p, typ, nil, "")
+		if err == nil {
+			t.Fatalf("separator should be rejected: %s", typ)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"reflect"
+)
+
+// Optional records presence of field value to distinguish absent, explicitly empty and provided values: Set is true
+// if any source has the key, Null is true if the value is a single empty string or null reported by NullSource,
+// otherwise Value is decoded.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Get returns the value and whether it's provided and not null.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+func (*Optional[T]) optionalValueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// field indexes of Optional.
+const (
+	optionalValueIndex = iota
+	optionalSetIndex
+	optionalNullIndex
+)
+
+type optionalValue interface {
+	optionalValueType() reflect.Type
+}
+
+var optionalValueIface = reflect.TypeOf((*optionalValue)(nil)).Elem()
+
+func isOptionalType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(optionalValueIface)
+}
+
+func optionalElemType(t reflect.Type) reflect.Type {
+	return reflect.New(t).Interface().(optionalValue).optionalValueType()
+}
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"mime/multipart"
 	"reflect"
 	"strings"
 	"sync"
@@ -23,24 +24,115 @@ func (f fieldSource) String() string {
 }
 
 type fieldInfo struct {
-	Sources  []fieldSource
-	Field    reflect.StructField
-	IsSlice  bool
-	Encoding Type
+	Sources []fieldSource
+	Field   reflect.StructField
+	Path    string
+	IsPtr   bool
+	IsSlice bool
+	IsArray bool
+	IsMap   bool
+	IsFile  bool
+	// IsOptional means the field type is Optional, other options describe the value type of it.
+	IsOptional bool
+	Encoding   Type
+	// ValueType is the type of decoded values, it's the field type without pointer, or element type of map.
+	ValueType reflect.Type
+	MapType   reflect.Type
+
+	Required   bool
+	Default    string
+	HasDefault bool
+	Sep        string
+	Rules      []validateRule
+}
+
+// structSliceInfo describes slice of structures, element fields are named with indexed prefix: name[index].field.
+type structSliceInfo struct {
+	Field reflect.StructField
+	Path  string
+	Name  string
+	Elem  *structureInfo
 }
 
 type structureInfo struct {
-	fields []fieldInfo
+	fields       []fieldInfo
+	structSlices []structSliceInfo
+	hooks        []structHook
 }
 
-// format: sources[;flags], sources: source[,source]*, flags: [inline]
+// findKey returns the field which the source name belongs to, including map entries and structure slice elements.
+// Names are also compared after canonicalized if canonical isn't nil, canonical must be idempotent, and canonical names
+// of map entries and slice elements may use any of "-_.:/" as separator such as environment variable names.
+func (s *structureInfo) findKey(source, name string, canonical func(string) string) (*fieldInfo, bool) {
+	cname := name
+	if canonical != nil {
+		cname = canonical(name)
+	}
+	for i := range s.fields {
+		field := &s.fields[i]
+		for _, src := range field.Sources {
+			if src.Source != source {
+				continue
+			}
+			srcName := src.Name
+			if canonical != nil {
+				srcName = canonical(srcName)
+			}
+			if srcName == cname {
+				return field, true
+			}
+			if field.IsMap {
+				if _, ok := mapKey(srcName, cname); ok {
+					return field, true
+				}
+				if canonical != nil && canonicalMapKey(src.Name, cname, canonical) {
+					return field, true
+				}
+			}
+		}
+	}
+	for i := range s.structSlices {
+		slice := &s.structSlices[i]
+		_, elemName, ok := sliceIndex(slice.Name, name)
+		if !ok && canonical != nil {
+			elemName, ok = canonicalSliceElem(canonical(slice.Name), cname)
+		}
+		if ok {
+			field, ok := slice.Elem.findKey(source, elemName, canonical)
+			if ok {
+				return field, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (s *structureInfo) hasKey(source, name string, canonical func(string) string) bool {
+	_, ok := s.findKey(source, name, canonical)
+	return ok
+}
+
+// format: sources[;flags], sources: source[,source]*, flags: flag[;flag]*,
+// flag: inline|required|default=value|sep=separator, separator: comma|pipe|space|semicolon|string
 type FieldOptions struct {
-	Sources []string
-	Inline  bool
+	Sources    []string
+	Inline     bool
+	Required   bool
+	Default    string
+	HasDefault bool
+	Sep        string
+}
+
+var separatorNames = map[string]string{
+	"comma":     ",",
+	"pipe":      "|",
+	"space":     " ",
+	"semicolon": ";",
 }
 
 type Parser struct {
 	optionsTag    string
+	validateTag   string
 	validSources  []string
 	nameConverter func(string) string
 
@@ -73,6 +165,11 @@ func NewParser(optionsTag string, validSources []string, fieldNameConverter func
 	return &p, nil
 }
 
+// SetValidateTag sets the tag name of field validation rules, it should be called before any structure is parsed.
+func (p *Parser) SetValidateTag(tag string) {
+	p.validateTag = tag
+}
+
 func (p *Parser) RegisterTypes(types ...Type) error {
 	for _, t := range types {
 		dt := reflect.TypeOf(t.DataType())
@@ -99,9 +196,23 @@ func (p *Parser) parseFieldOptions(val string) FieldOptions {
 	if l > 1 {
 		flags := splitNonEmptyAndTrim(secs[1], ";")
 		for _, flag := range flags {
-			switch flag {
+			name, value := flag, ""
+			if i := strings.IndexByte(flag, '='); i >= 0 {
+				name, value = flag[:i], flag[i+1:]
+			}
+			switch name {
 			case "inline":
 				options.Inline = true
+			case "required":
+				options.Required = true
+			case "default":
+				options.Default = value
+				options.HasDefault = true
+			case "sep":
+				options.Sep = value
+				if sep, has := separatorNames[value]; has {
+					options.Sep = sep
+				}
 			}
 		}
 	}
@@ -137,19 +248,103 @@ func (p *Parser) newContext(context, name string) string {
 	return context + "." + name
 }
 
-func (p *Parser) isSupportedOrBySlice(t reflect.Type) (isSlice bool, enc Type, ok bool) {
+// lookupType returns the registered type, if not found, fallback to encoding.TextUnmarshaler, then the type registered
+// for the underlying kind of named types.
+func (p *Parser) lookupType(t reflect.Type) (Type, bool) {
 	enc, has := p.supportTypes[t]
+	if has {
+		return enc, true
+	}
+	enc, has = newTextType(t)
+	if has {
+		return enc, true
+	}
+	underlying, has := kindTypes[t.Kind()]
+	if !has || underlying == t {
+		return nil, false
+	}
+	enc, has = p.supportTypes[underlying]
+	if !has {
+		return nil, false
+	}
+	return convertType{typ: t, underlying: underlying, enc: enc}, true
+}
+
+func (p *Parser) isSupportedOrBySlice(t reflect.Type) (isSlice bool, enc Type, ok bool) {
+	enc, has := p.lookupType(t)
 	if has {
 		return false, enc, true
 	}
-	if t.Kind() == reflect.Slice {
-		enc, has := p.supportTypes[t.Elem()]
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		enc, has := p.lookupType(t.Elem())
 		if has {
 			return true, enc, true
 		}
 	}
 	return false, nil, false
 }
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// fieldEncoding returns field info filled with encoding details if the type is supported, pointer of supported type
+// and map keyed by string with supported value type is also allowed. Arrays are treated as slices with fixed length.
+// *multipart.FileHeader and []*multipart.FileHeader is decoded from FileSource. Optional is encoded as its value type.
+func (p *Parser) fieldEncoding(t reflect.Type) (fieldInfo, bool) {
+	if isOptionalType(t) {
+		elemt := optionalElemType(t)
+		if isOptionalType(elemt) {
+			return fieldInfo{}, false
+		}
+		info, ok := p.fieldEncoding(elemt)
+		if !ok || info.IsFile {
+			return fieldInfo{}, false
+		}
+		info.IsOptional = true
+		return info, true
+	}
+
+	var info fieldInfo
+	if t == fileHeaderType || t == fileHeadersType {
+		info.IsFile = true
+		info.IsSlice = t == fileHeadersType
+		info.ValueType = t
+		return info, true
+	}
+	if t.Kind() == reflect.Ptr {
+		_, has := p.lookupType(t)
+		if !has {
+			info.IsPtr = true
+			t = t.Elem()
+		}
+	}
+	isSlice, enc, ok := p.isSupportedOrBySlice(t)
+	if !ok && t.Kind() == reflect.Map && t.Key().Kind() == reflect.String {
+		info.IsMap = true
+		info.MapType = t
+		t = t.Elem()
+		isSlice, enc, ok = p.isSupportedOrBySlice(t)
+	}
+	if !ok {
+		return fieldInfo{}, false
+	}
+	info.IsSlice = isSlice
+	info.IsArray = isSlice && t.Kind() == reflect.Array
+	info.Encoding = enc
+	info.ValueType = t
+	return info, true
+}
+
+func (p *Parser) isParsing(parents []reflect.Type, t reflect.Type) bool {
+	for _, parent := range parents {
+		if parent == t {
+			return true
+		}
+	}
+	return false
+}
 func (p *Parser) newIndex(parent, index []int) []int {
 	if len(parent) > 0 {
 		nindex := make([]int, 0, len(parent)+len(index))
@@ -159,15 +354,19 @@ func (p *Parser) newIndex(parent, index []int) []int {
 	}
 	return index
 }
-func (p *Parser) parse(typ reflect.Type) (*structureInfo, error) {
+func (p *Parser) parse(typ reflect.Type, parents []reflect.Type) (*structureInfo, error) {
 	type parseNode struct {
 		Type    reflect.Type
 		Index   []int
 		Context string
+		Path    string
+		Parents []reflect.Type
+
+		Anonymous bool
 	}
 	var (
 		typeInfo   structureInfo
-		parseQueue = []parseNode{{Type: typ, Context: ""}}
+		parseQueue = []parseNode{{Type: typ, Context: "", Parents: parents}}
 	)
 
 	for {
@@ -178,6 +377,9 @@ func (p *Parser) parse(typ reflect.Type) (*structureInfo, error) {
 		node := parseQueue[0]
 		copy(parseQueue, parseQueue[1:])
 		parseQueue = parseQueue[:l-1]
+		if !node.Anonymous && hasHooks(node.Type) {
+			typeInfo.hooks = append(typeInfo.hooks, structHook{Index: node.Index, Path: node.Path})
+		}
 
 		for i := 0; i < node.Type.NumField(); i++ {
 			f := node.Type.Field(i)
@@ -187,14 +389,46 @@ func (p *Parser) parse(typ reflect.Type) (*structureInfo, error) {
 			}
 			options := p.parseFieldOptions(f.Tag.Get(p.optionsTag))
 
-			isSlice, enc, ok := p.isSupportedOrBySlice(f.Type)
+			info, ok := p.fieldEncoding(f.Type)
 			if !ok {
-				if f.Type.Kind() == reflect.Struct {
-					if f.Anonymous || options.Inline {
-						parseQueue = append(parseQueue, parseNode{Type: f.Type, Context: node.Context, Index: p.newIndex(node.Index, f.Index)})
-					} else {
-						parseQueue = append(parseQueue, parseNode{Type: f.Type, Context: p.newContext(node.Context, name), Index: p.newIndex(node.Index, f.Index)})
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					parents := append(node.Parents[:len(node.Parents):len(node.Parents)], node.Type)
+					if p.isParsing(parents, ft) {
+						continue
+					}
+					child := parseNode{
+						Type:    ft,
+						Context: node.Context,
+						Index:   p.newIndex(node.Index, f.Index),
+						Path:    p.newContext(node.Path, f.Name),
+						Parents: parents,
+
+						Anonymous: f.Anonymous,
 					}
+					if !f.Anonymous && !options.Inline {
+						child.Context = p.newContext(node.Context, name)
+					}
+					parseQueue = append(parseQueue, child)
+				} else if ft == f.Type && ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+					parents := append(node.Parents[:len(node.Parents):len(node.Parents)], node.Type)
+					if p.isParsing(parents, ft.Elem()) {
+						continue
+					}
+					elem, err := p.parse(ft.Elem(), parents)
+					if err != nil {
+						return nil, err
+					}
+					f.Index = p.newIndex(node.Index, f.Index)
+					typeInfo.structSlices = append(typeInfo.structSlices, structSliceInfo{
+						Field: f,
+						Path:  p.newContext(node.Path, f.Name),
+						Name:  p.newContext(node.Context, name),
+						Elem:  elem,
+					})
 				} else if len(options.Sources) > 0 {
 					return nil, fmt.Errorf("unsupported field type: %s, %s: %s", p.newContext(typ.String(), node.Context), name, f.Type.String())
 				}
@@ -206,6 +440,12 @@ func (p *Parser) parse(typ reflect.Type) (*structureInfo, error) {
 			if !p.isFieldSourcesValid(options.Sources) {
 				return nil, fmt.Errorf("invalid source: field: %s, options.Sources: %v", f.Name, options.Sources)
 			}
+			if (info.IsMap || info.IsFile || info.IsOptional) && options.HasDefault {
+				return nil, fmt.Errorf("default value of map, file or Optional field is not supported: field: %s", f.Name)
+			}
+			if options.Sep != "" && (!info.IsSlice || info.IsFile) {
+				return nil, fmt.Errorf("separator is only supported by slice field: field: %s", f.Name)
+			}
 
 			fieldSources := make([]fieldSource, 0, len(options.Sources))
 			for i, src := range options.Sources {
@@ -224,19 +464,30 @@ func (p *Parser) parse(typ reflect.Type) (*structureInfo, error) {
 				fieldSources = append(fieldSources, source)
 			}
 
+			if p.validateTag != "" {
+				rules, err := p.parseValidateRules(&info, f.Tag.Get(p.validateTag))
+				if err != nil {
+					return nil, fmt.Errorf("invalid validation rules: field: %s, %s", f.Name, err.Error())
+				}
+				info.Rules = rules
+			}
+
 			f.Index = p.newIndex(node.Index, f.Index)
-			typeInfo.fields = append(typeInfo.fields, fieldInfo{
-				Sources:  fieldSources,
-				Field:    f,
-				IsSlice:  isSlice,
-				Encoding: enc,
-			})
+			info.Sources = fieldSources
+			info.Field = f
+			info.Path = p.newContext(node.Path, f.Name)
+			info.Required = options.Required
+			info.Default = options.Default
+			info.HasDefault = options.HasDefault
+			info.Sep = options.Sep
+			typeInfo.fields = append(typeInfo.fields, info)
 		}
 	}
 	return &typeInfo, nil
 }
 
-func (p *Parser) Parse(t reflect.Type) (*structureInfo, error) {
+// parseStructure returns the cached structure info, the structure is parsed at the first time.
+func (p *Parser) parseStructure(t reflect.Type) (*structureInfo, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("source type isn't structure: %s", t.String())
 	}
@@ -246,7 +497,7 @@ func (p *Parser) Parse(t reflect.Type) (*structureInfo, error) {
 	if has {
 		return info, nil
 	}
-	info, err := p.parse(t)
+	info, err := p.parse(t, nil)
 	if err != nil {
 		return nil, fmt.Errorf("invalid type schema: %s, %s", t.String(), err.Error())
 	}
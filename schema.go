@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"reflect"
+)
+
+// SchemaSource is a source and the key of field in the source.
+type SchemaSource struct {
+	Source string
+	Name   string
+}
+
+// SchemaRule is a validation rule of field.
+type SchemaRule struct {
+	Name  string
+	Param string
+}
+
+// SchemaField describes a field bound to sources, Path is the go field path such as "Embed.Embed", Field.Index is
+// the index from the root structure.
+type SchemaField struct {
+	Path    string
+	Field   reflect.StructField
+	Sources []SchemaSource
+	// ValueType is the type of decoded values, it's the field type without pointer and Optional, or element type of
+	// map.
+	ValueType  reflect.Type
+	IsPtr      bool
+	IsSlice    bool
+	IsArray    bool
+	IsMap      bool
+	IsFile     bool
+	IsOptional bool
+
+	Required   bool
+	Default    string
+	HasDefault bool
+	Sep        string
+	Rules      []SchemaRule
+}
+
+// Source returns the name of field in source, false is returned if field isn't bound to the source.
+func (f *SchemaField) Source(source string) (string, bool) {
+	for _, src := range f.Sources {
+		if src.Source == source {
+			return src.Name, true
+		}
+	}
+	return "", false
+}
+
+// SchemaStructSlice describes slice of structures, fields of elements are named as Name[index].name in sources.
+type SchemaStructSlice struct {
+	Path  string
+	Field reflect.StructField
+	Name  string
+	Elem  *Schema
+}
+
+// Schema is the read-only description of parsed structure, it's rebuilt for each Parse call and modifications doesn't
+// affect decoding and encoding.
+type Schema struct {
+	Type         reflect.Type
+	Fields       []SchemaField
+	StructSlices []SchemaStructSlice
+}
+
+func newSchema(t reflect.Type, typInfo *structureInfo) *Schema {
+	s := Schema{
+		Type:         t,
+		Fields:       make([]SchemaField, 0, len(typInfo.fields)),
+		StructSlices: make([]SchemaStructSlice, 0, len(typInfo.structSlices)),
+	}
+	for i := range typInfo.fields {
+		field := &typInfo.fields[i]
+		sf := SchemaField{
+			Path:       field.Path,
+			Field:      field.Field,
+			Sources:    make([]SchemaSource, 0, len(field.Sources)),
+			ValueType:  field.ValueType,
+			IsPtr:      field.IsPtr,
+			IsSlice:    field.IsSlice,
+			IsArray:    field.IsArray,
+			IsMap:      field.IsMap,
+			IsFile:     field.IsFile,
+			IsOptional: field.IsOptional,
+			Required:   field.Required,
+			Default:    field.Default,
+			HasDefault: field.HasDefault,
+			Sep:        field.Sep,
+		}
+		sf.Field.Index = append([]int(nil), field.Field.Index...)
+		for _, src := range field.Sources {
+			sf.Sources = append(sf.Sources, SchemaSource{Source: src.Source, Name: src.Name})
+		}
+		for _, rule := range field.Rules {
+			sf.Rules = append(sf.Rules, SchemaRule{Name: rule.Name, Param: rule.Param})
+		}
+		s.Fields = append(s.Fields, sf)
+	}
+	for i := range typInfo.structSlices {
+		slice := &typInfo.structSlices[i]
+		ss := SchemaStructSlice{
+			Path:  slice.Path,
+			Field: slice.Field,
+			Name:  slice.Name,
+			Elem:  newSchema(slice.Field.Type.Elem(), slice.Elem),
+		}
+		ss.Field.Index = append([]int(nil), slice.Field.Index...)
+		s.StructSlices = append(s.StructSlices, ss)
+	}
+	return &s
+}
+
+// Parse parses structure type t and returns the description of fields bound to sources.
+func (p *Parser) Parse(t reflect.Type) (*Schema, error) {
+	typInfo, err := p.parseStructure(t)
+	if err != nil {
+		return nil, err
+	}
+	return newSchema(t, typInfo), nil
+}
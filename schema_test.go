@@ -1,11 +1,14 @@
 package schema_test
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -20,7 +23,8 @@ var (
 )
 
 func init() {
-	p, err := schema.NewParser("schema", []string{"path", "query", "form", "header", "body"}, func(v string) string { return v })
+	var err error
+	p, err = schema.NewParser("schema", []string{"path", "query", "form", "header", "body"}, func(v string) string { return v })
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -44,6 +48,17 @@ func (s Sources) Get(source, name string) []string {
 	return s[source][name]
 }
 
+func (s Sources) Keys(source, prefix string) []string {
+	var keys []string
+	for name := range s[source] {
+		if strings.HasPrefix(name, prefix) {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s Sources) Set(source, name string, vals []string) (bool, error) {
 	svals, has := s[source]
 	if !has {
@@ -188,21 +203,612 @@ func TestSchema(t *testing.T) {
 	}
 }
 
-type httpRequestSource struct {
-	req *http.Request
+func TestDecodeErrors(t *testing.T) {
+	type Embed struct {
+		Uint32 uint32 `schema:"header"`
+	}
+	type TestDecoderStruct struct {
+		Int   int  `schema:"query"`
+		Bool  bool `schema:"query"`
+		Embed Embed
+	}
+	src := Sources{
+		"query": url.Values{
+			"Int":  []string{"x"},
+			"Bool": []string{"true"},
+		},
+		"header": url.Values{
+			"Embed.Uint32": []string{"-1"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	var errs schema.DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect DecodeErrors, but got %v", err)
+	}
+	if len(errs) != 2 || errs[0].Field != "Int" || errs[1].Field != "Embed.Uint32" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if errs[1].Source != "header" || errs[1].Name != "Embed.Uint32" || !reflect.DeepEqual(errs[1].Values, []string{"-1"}) {
+		t.Fatalf("unexpected field error: %+v", errs[1])
+	}
+	var fieldErr *schema.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "Int" {
+		t.Fatalf("expect FieldError, but got %v", err)
+	}
+	if !data.Bool {
+		t.Fatal("valid fields should be decoded")
+	}
+}
+
+func TestRequiredAndDefault(t *testing.T) {
+	type TestDecoderStruct struct {
+		Page   int      `schema:"query;default=1"`
+		Sizes  []int    `schema:"query;default=10"`
+		Name   string   `schema:"query;required"`
+		Token  string   `schema:"query,header;required"`
+		Filter []string `schema:"query;required"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"Name": []string{""},
+		},
+		"header": url.Values{
+			"Token": []string{"token"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	var errs schema.DecodeErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expect 2 errors, but got %v", err)
+	}
+	if errs[0].Field != "Name" || errs[1].Field != "Filter" || !errors.Is(errs[1], schema.ErrRequired) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	expectData := TestDecoderStruct{Page: 1, Sizes: []int{10}, Token: "token"}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+	type Embed struct {
+		Name string `schema:"query"`
+	}
+	type Node struct {
+		Value int `schema:"query"`
+		Next  *Node
+	}
+	type TestDecoderStruct struct {
+		Int    *int    `schema:"query"`
+		String *string `schema:"query"`
+		Ints   *[]int  `schema:"query"`
+		Embed  *Embed
+		Absent *Embed `schema:";inline"`
+		Node   Node
+		*Node2
+	}
+	src := Sources{
+		"query": url.Values{
+			"Int":        []string{"0"},
+			"Ints":       []string{"1", "2"},
+			"Embed.Name": []string{"name"},
+			"Node.Value": []string{"0"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Int == nil || *data.Int != 0 || data.String != nil || data.Ints == nil || !reflect.DeepEqual(*data.Ints, []int{1, 2}) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+	if data.Embed == nil || data.Embed.Name != "name" || data.Absent != nil || data.Node2 != nil {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(data, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+}
+
+type Node2 struct {
+	Value2 int `schema:"query"`
 }
 
-func (h *httpRequestSource) Get(source, name string) []string {
-	switch source {
-	case "body":
-		_ = h.req.ParseForm()
-		return h.req.PostForm[name]
-	case "query":
-		return h.req.URL.Query()[name]
-	case "header":
-		return h.req.Header[name]
+type Color int
+
+func (c Color) MarshalText() ([]byte, error) {
+	switch c {
+	case 1:
+		return []byte("red"), nil
+	case 2:
+		return []byte("green"), nil
+	}
+	return nil, fmt.Errorf("invalid color: %d", int(c))
+}
+
+func (c *Color) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "red":
+		*c = 1
+	case "green":
+		*c = 2
 	default:
-		return nil
+		return fmt.Errorf("invalid color: %s", b)
+	}
+	return nil
+}
+
+func TestTextTypes(t *testing.T) {
+	type TestDecoderStruct struct {
+		IP     net.IP   `schema:"query"`
+		IPs    []net.IP `schema:"query"`
+		Color  Color    `schema:"query"`
+		Colors []Color  `schema:"query"`
+		PColor *Color   `schema:"query"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"IP":     []string{"127.0.0.1"},
+			"IPs":    []string{"10.0.0.1", "::1"},
+			"Color":  []string{"red"},
+			"Colors": []string{"green", "red"},
+			"PColor": []string{"green"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	green := Color(2)
+	expectData := TestDecoderStruct{
+		IP:     net.ParseIP("127.0.0.1"),
+		IPs:    []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")},
+		Color:  1,
+		Colors: []Color{2, 1},
+		PColor: &green,
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+}
+
+func TestNamedTypes(t *testing.T) {
+	type UserID int64
+	type Role string
+	type TestDecoderStruct struct {
+		ID    UserID  `schema:"query"`
+		Roles []Role  `schema:"query"`
+		Role  *Role   `schema:"query"`
+		Rate  float64 `schema:"query"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"ID":    []string{"10"},
+			"Roles": []string{"admin", "user"},
+			"Role":  []string{"user"},
+			"Rate":  []string{"0.5"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	role := Role("user")
+	expectData := TestDecoderStruct{ID: 10, Roles: []Role{"admin", "user"}, Role: &role, Rate: 0.5}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+}
+
+func TestMapFields(t *testing.T) {
+	type TestDecoderStruct struct {
+		Meta   map[string]string  `schema:"header" header:"X-Meta-"`
+		Filter map[string][]int   `schema:"query"`
+		Labels *map[string]string `schema:"query"`
+		Empty  map[string]string  `schema:"query"`
+	}
+	src := Sources{
+		"header": url.Values{
+			"X-Meta-Name": []string{"name"},
+			"X-Meta-Type": []string{"type"},
+		},
+		"query": url.Values{
+			"Filter[id]":  []string{"1", "2"},
+			"Filter[age]": []string{"10"},
+			"Labels[env]": []string{"dev"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := TestDecoderStruct{
+		Meta:   map[string]string{"Name": "name", "Type": "type"},
+		Filter: map[string][]int{"id": {1, 2}, "age": {10}},
+		Labels: &map[string]string{"env": "dev"},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+
+	src["query"]["Filter.id"] = []string{"x"}
+	err = d.Decode(src, &data)
+	var fieldErr *schema.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Name != "Filter.id" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delete(src["query"], "Filter.id")
+	err = d.Decode(getOnlySource{src}, &data)
+	if err == nil {
+		t.Fatal("map field should fail for non-enumerable source")
+	}
+	err = d.Decode(schema.MultiSource{"query": getOnlySource{src}, "header": src}, &data)
+	if err == nil {
+		t.Fatal("map field should fail for non-enumerable source routed by MultiSource")
+	}
+
+	var labels struct {
+		Labels map[string]string `schema:"query,header"`
+	}
+	err = d.Decode(schema.MultiSource{"query": src}, &labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(labels.Labels, map[string]string{"env": "dev"}) {
+		t.Fatalf("unexpected decode result: %+v", labels)
+	}
+}
+
+func TestArrayFields(t *testing.T) {
+	type TestDecoderStruct struct {
+		Point [2]float64        `schema:"query"`
+		Hash  *[4]byte          `schema:"query"`
+		Roles [2]string         `schema:"query"`
+		IDs   [0]int            `schema:"query"`
+		Pairs map[string][2]int `schema:"query"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"Point":    []string{"1.5", "-2"},
+			"Hash":     []string{"1", "2", "3", "4"},
+			"Roles":    []string{"a", "b"},
+			"Pairs[x]": []string{"1", "2"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := TestDecoderStruct{
+		Point: [2]float64{1.5, -2},
+		Hash:  &[4]byte{1, 2, 3, 4},
+		Roles: [2]string{"a", "b"},
+		Pairs: map[string][2]int{"x": {1, 2}},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+
+	src["query"]["Point"] = []string{"1"}
+	err = d.Decode(src, &data)
+	var lengthErr *schema.ArrayLengthError
+	if !errors.As(err, &lengthErr) || lengthErr.Length != 2 || lengthErr.Got != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	type TestDecoderStruct struct {
+		IDs    []int     `schema:"query;sep=,"`
+		Names  []string  `schema:"query;sep=pipe"`
+		Point  [2]int    `schema:"query;sep=space"`
+		Sizes  []int     `schema:"query;sep=comma;default=1,2"`
+		Ranges []float64 `schema:"query;sep=.."`
+	}
+	src := Sources{
+		"query": url.Values{
+			"IDs":    []string{"1,2,3"},
+			"Names":  []string{"a|b"},
+			"Point":  []string{"1 2"},
+			"Ranges": []string{"1..2.5"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := TestDecoderStruct{
+		IDs:    []int{1, 2, 3},
+		Names:  []string{"a", "b"},
+		Point:  [2]int{1, 2},
+		Sizes:  []int{1, 2},
+		Ranges: []float64{1, 2.5},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src["query"]["Sizes"] = []string{"1,2"}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	type Item struct {
+		Name string `schema:"query"`
+	}
+	type TestDecoderStruct struct {
+		Page   int               `schema:"query"`
+		Filter map[string]string `schema:"query"`
+		Items  []Item
+		Token  string `schema:"header"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"Page":          []string{"1"},
+			"pgae":          []string{"2"},
+			"Filter[name]":  []string{"a"},
+			"Items[0].Name": []string{"a"},
+			"Items[0].Size": []string{"1"},
+		},
+		"header": url.Values{
+			"User-Agent": []string{"go"},
+		},
+	}
+
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetStrict("query")
+	var data TestDecoderStruct
+	err = d.Decode(src, &data)
+	var errs schema.DecodeErrors
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(err, schema.ErrUnknownKey) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errs[0].Source != "query" || errs[0].Name != "Items[0].Size" || errs[1].Name != "pgae" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if data.Page != 1 || len(data.Items) != 1 {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	err = d.Decode(getOnlySource{src}, &data)
+	if err == nil {
+		t.Fatal("strict mode should fail for non-enumerable source")
+	}
+	err = d.Decode(schema.MultiSource{"query": getOnlySource{src}, "header": src}, &data)
+	if err == nil {
+		t.Fatal("strict mode should fail for non-enumerable source routed by MultiSource")
+	}
+}
+
+func TestLayeredSources(t *testing.T) {
+	type Config struct {
+		Host  string   `schema:"flag,env,file;default=localhost"`
+		Port  int      `schema:"flag,env,file;default=80"`
+		Name  string   `schema:"flag,env,file"`
+		Tags  []string `schema:"flag,env,file"`
+		Debug bool     `schema:"file,env,flag"`
+	}
+	p := newConfigParser(t)
+	flags, err := schema.NewFlagSource(p, reflect.TypeOf(Config{}), "flag", []string{"--name", "flag", "--debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := schema.NewEnvSource("env", "APP", ",", map[string]string{
+		"APP_NAME":  "env",
+		"APP_PORT":  "8080",
+		"APP_TAGS":  "a,b",
+		"APP_DEBUG": "false",
+	})
+	file, err := schema.NewINISource("file", strings.NewReader("name = file\nport = 9090\ntags = c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := schema.MultiSource{"flag": flags, "env": env, "file": file}
+
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data Config
+	err = d.Decode(src, &data)
+	if err == nil {
+		t.Fatal("duplicated values should fail in strict precedence")
+	}
+
+	d.SetPrecedence(schema.PrecedenceFirst, "flag", "env", "file")
+	data = Config{}
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := Config{Host: "localhost", Port: 8080, Name: "flag", Tags: []string{"a", "b"}, Debug: true}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	d.SetPrecedence(schema.PrecedenceLast)
+	data = Config{}
+	err = d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData = Config{Host: "localhost", Port: 9090, Name: "file", Tags: []string{"c"}, Debug: true}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+}
+
+type getOnlySource struct {
+	s schema.DecoderSource
+}
+
+func (s getOnlySource) Get(source, name string) []string {
+	return s.s.Get(source, name)
+}
+
+func TestStructSlices(t *testing.T) {
+	type Tag struct {
+		Name string `schema:"form"`
+	}
+	type Item struct {
+		Name  string `schema:"form"`
+		Count int    `schema:"form;required"`
+		Tags  []Tag
+	}
+	type TestDecoderStruct struct {
+		Items []Item
+	}
+	src := Sources{
+		"form": url.Values{
+			"Items[0].Name":         []string{"a"},
+			"Items[0].Count":        []string{"1"},
+			"Items[0].Tags[0].Name": []string{"x"},
+			"Items[0].Tags[1].Name": []string{"y"},
+			"Items[1].Name":         []string{"b"},
+			"Items[1].Count":        []string{"2"},
+		},
+	}
+	expectData := TestDecoderStruct{
+		Items: []Item{
+			{Name: "a", Count: 1, Tags: []Tag{{Name: "x"}, {Name: "y"}}},
+			{Name: "b", Count: 2},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decode result: %+v", data)
+	}
+
+	var dst = make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("unexpected encode result: %+v\n", dst)
+	}
+
+	dotSrc := Sources{
+		"form": url.Values{
+			"Items.0.Name":        []string{"a"},
+			"Items.0.Count":       []string{"1"},
+			"Items.0.Tags.0.Name": []string{"x"},
+			"Items.0.Tags.1.Name": []string{"y"},
+			"Items.1.Name":        []string{"b"},
+			"Items.1.Count":       []string{"2"},
+		},
+	}
+	for _, s := range []schema.DecoderSource{dotSrc, getOnlySource{src}, getOnlySource{dotSrc}, schema.MultiSource{"form": getOnlySource{src}}} {
+		data = TestDecoderStruct{}
+		err = d.Decode(s, &data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, expectData) {
+			t.Fatalf("unexpected decode result: %+v", data)
+		}
+	}
+
+	sparseSrc := Sources{
+		"form": url.Values{
+			"Items[999].Name":           []string{"a"},
+			"Items[999].Count":          []string{"1"},
+			"Items[999].Tags[500].Name": []string{"x"},
+			"Items[5].Name":             []string{"b"},
+			"Items[5].Count":            []string{"2"},
+		},
+	}
+	data = TestDecoderStruct{}
+	err = d.Decode(sparseSrc, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSparse := TestDecoderStruct{
+		Items: []Item{{Name: "b", Count: 2}, {Name: "a", Count: 1, Tags: []Tag{{Name: "x"}}}},
+	}
+	if !reflect.DeepEqual(data, expectSparse) {
+		t.Fatalf("sparse indexes should be compacted: %+v", data)
+	}
+
+	delete(src["form"], "Items[1].Count")
+	err = d.Decode(src, &data)
+	var fieldErr *schema.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "Items[1].Count" || fieldErr.Name != "Items[1].Count" || !errors.Is(err, schema.ErrRequired) {
+		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
@@ -271,7 +877,12 @@ func ExampleDecoder_Decode() {
 		log.Fatal(err)
 	}
 	var reqData QueryRequest
-	err = d.Decode(&httpRequestSource{&httpreq}, &reqData)
+	src := schema.NewHTTPRequestSource(&httpreq, map[string]schema.HTTPPart{
+		"body":   schema.HTTPForm,
+		"query":  schema.HTTPQuery,
+		"header": schema.HTTPHeader,
+	})
+	err = d.Decode(src, &reqData)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -279,3 +890,194 @@ func ExampleDecoder_Decode() {
 	fmt.Printf("%s %s %s %d\n", reqData.Name, reqData.Date.Format("2006-01-02"), reqData.AccessToken, reqData.Page)
 	// Output: Someone 2018-12-25 Token 3
 }
+
+func TestDecodeResult(t *testing.T) {
+	type Item struct {
+		Name string `schema:"query"`
+	}
+	type TestDecoderStruct struct {
+		Name  string            `schema:"header,query"`
+		Page  int               `schema:"query;default=1"`
+		Size  int               `schema:"query"`
+		Meta  map[string]string `schema:"header" header:"X-Meta-"`
+		Items []Item
+	}
+	src := Sources{
+		"query": url.Values{
+			"Name":          []string{"name"},
+			"Items[0].Name": []string{"a"},
+		},
+		"header": url.Values{
+			"X-Meta-Lang": []string{"go"},
+		},
+	}
+
+	var data TestDecoderStruct
+	result, err := d.DecodeWithResult(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFields := map[string]schema.FieldResult{
+		"Name":          {Source: "query", Name: "Name", Values: []string{"name"}},
+		"Page":          {Values: []string{"1"}, Default: true},
+		"Meta":          {Source: "header", Name: "X-Meta-"},
+		"Items[0].Name": {Source: "query", Name: "Items[0].Name", Values: []string{"a"}},
+	}
+	if !reflect.DeepEqual(result.Fields, expectFields) {
+		t.Fatalf("unexpected decode result: %+v", result.Fields)
+	}
+	if !result.Has("Name") || result.Has("Page") || result.Has("Size") {
+		t.Fatal("unexpected presence of fields")
+	}
+}
+
+func TestOptional(t *testing.T) {
+	type TestDecoderStruct struct {
+		Name  schema.Optional[string]   `schema:"query"`
+		Age   schema.Optional[int]      `schema:"query"`
+		Tags  schema.Optional[[]string] `schema:"query"`
+		Color schema.Optional[Color]    `schema:"query"`
+	}
+	src := Sources{
+		"query": url.Values{
+			"Name": []string{""},
+			"Age":  []string{"0"},
+		},
+	}
+
+	var data TestDecoderStruct
+	err := d.Decode(src, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectData := TestDecoderStruct{
+		Name: schema.Optional[string]{Set: true, Null: true},
+		Age:  schema.Optional[int]{Set: true},
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Fatalf("unexpected decoded data: %+v", data)
+	}
+	if _, ok := data.Name.Get(); ok {
+		t.Fatal("null value should not be available")
+	}
+	if age, ok := data.Age.Get(); !ok || age != 0 {
+		t.Fatal("zero value should be available")
+	}
+
+	err = d.Decode(Sources{"query": url.Values{"Age": []string{"abc"}}}, &data)
+	if err == nil {
+		t.Fatal("invalid value should be reported")
+	}
+
+	dst := make(Sources)
+	err = e.Encode(expectData, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst, src) {
+		t.Fatalf("unexpected encoded data: %+v", dst)
+	}
+	var withDefault struct {
+		Name schema.Optional[string] `schema:"query;default=name"`
+	}
+	err = d.Decode(src, &withDefault)
+	if err == nil {
+		t.Fatal("default value of Optional field should fail")
+	}
+
+	jsonSrc, err := schema.NewJSONSource("query", []byte(`{"Name": null, "Age": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = TestDecoderStruct{}
+	err = d.Decode(jsonSrc, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Name.Null || !data.Name.Set || data.Tags.Set {
+		t.Fatalf("unexpected decoded data: %+v", data)
+	}
+
+	var plain struct {
+		Tags []string `schema:"query"`
+	}
+	err = d.Decode(Sources{"query": url.Values{"Tags": []string{""}}}, &plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plain.Tags, []string{""}) {
+		t.Fatalf("unexpected decoded data: %+v", plain)
+	}
+	plain.Tags = nil
+	jsonSrc, err = schema.NewJSONSource("query", []byte(`{"Tags": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Decode(jsonSrc, &plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Tags != nil {
+		t.Fatalf("null value should be ignored by non-Optional fields: %+v", plain)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	type Item struct {
+		Name string `schema:"query"`
+	}
+	type Filter struct {
+		Tags []string `schema:"query;sep=comma"`
+	}
+	type TestDecoderStruct struct {
+		ID     int                     `schema:"path,query;required"`
+		Page   *int                    `schema:"query;default=1"`
+		Meta   map[string]string       `schema:"header" header:"X-Meta-"`
+		Note   schema.Optional[string] `schema:"query"`
+		Filter Filter
+		Items  []Item
+		Ignore string
+	}
+	s, err := p.Parse(reflect.TypeOf(TestDecoderStruct{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != reflect.TypeOf(TestDecoderStruct{}) || len(s.Fields) != 5 || len(s.StructSlices) != 1 {
+		t.Fatalf("unexpected schema: %+v", s)
+	}
+
+	id := s.Fields[0]
+	if id.Path != "ID" || !id.Required || id.ValueType != reflect.TypeOf(0) {
+		t.Fatalf("unexpected field: %+v", id)
+	}
+	if name, ok := id.Source("query"); !ok || name != "ID" {
+		t.Fatal("unexpected source name of field")
+	}
+	if _, ok := id.Source("header"); ok {
+		t.Fatal("field isn't bound to header")
+	}
+	if page := s.Fields[1]; !page.IsPtr || !page.HasDefault || page.Default != "1" {
+		t.Fatalf("unexpected field: %+v", page)
+	}
+	if meta := s.Fields[2]; !meta.IsMap || meta.Sources[0] != (schema.SchemaSource{Source: "header", Name: "X-Meta-"}) {
+		t.Fatalf("unexpected field: %+v", meta)
+	}
+	if note := s.Fields[3]; !note.IsOptional || note.ValueType != reflect.TypeOf("") {
+		t.Fatalf("unexpected field: %+v", note)
+	}
+	if tags := s.Fields[4]; tags.Path != "Filter.Tags" || tags.Sources[0].Name != "Filter.Tags" || !tags.IsSlice || tags.Sep != "," {
+		t.Fatalf("unexpected field: %+v", tags)
+	}
+	if items := s.StructSlices[0]; items.Name != "Items" || items.Elem.Fields[0].Path != "Name" {
+		t.Fatalf("unexpected structure slice: %+v", items)
+	}
+
+	s.Fields[0].Sources[0].Name = "modified"
+	s, err = p.Parse(reflect.TypeOf(TestDecoderStruct{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Fields[0].Sources[0].Name != "ID" {
+		t.Fatal("schema modifications should not affect parser")
+	}
+}
@@ -1,9 +1,11 @@
 package schema
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 )
 
 func BuiltinTypes() []Type {
@@ -306,3 +308,106 @@ func (stringType) Encode(val interface{}) (s string, err error) {
 	}
 	return v, nil
 }
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// textType is used for types implements encoding.TextUnmarshaler but not registered, encoding.TextMarshaler is
+// required for encoding.
+type textType struct {
+	typ reflect.Type
+}
+
+func newTextType(t reflect.Type) (Type, bool) {
+	if t.Kind() == reflect.Interface || !reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return nil, false
+	}
+	return textType{typ: t}, true
+}
+
+func (t textType) DataType() interface{} { return reflect.Zero(t.typ).Interface() }
+
+func (t textType) Decode(s string) (val interface{}, err error) {
+	v := reflect.New(t.typ)
+	err = v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value(%s): %s, %s", t.typ, s, err.Error())
+	}
+	return v.Elem().Interface(), nil
+}
+
+func (t textType) Encode(val interface{}) (s string, err error) {
+	v := reflect.ValueOf(val)
+	if !v.IsValid() || v.Type() != t.typ {
+		return "", fmt.Errorf("invalid data type, expect %s, but got %s", t.typ, reflect.TypeOf(val))
+	}
+	m, ok := val.(encoding.TextMarshaler)
+	if !ok {
+		ptr := reflect.New(t.typ)
+		ptr.Elem().Set(v)
+		m, ok = ptr.Interface().(encoding.TextMarshaler)
+		if !ok {
+			return "", fmt.Errorf("data type doesn't implement encoding.TextMarshaler: %s", t.typ)
+		}
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema describes time.Time as date-time string, values of other types are strings.
+func (t textType) JSONSchema() map[string]interface{} {
+	if t.typ == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+// convertType is used for named types such as `type Status int`, values are converted between the named type and the
+// type registered for it's underlying kind.
+type convertType struct {
+	typ        reflect.Type
+	underlying reflect.Type
+	enc        Type
+}
+
+func (t convertType) DataType() interface{} { return reflect.Zero(t.typ).Interface() }
+
+func (t convertType) Decode(s string) (val interface{}, err error) {
+	val, err = t.enc.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(val).Convert(t.typ).Interface(), nil
+}
+
+func (t convertType) Encode(val interface{}) (s string, err error) {
+	v := reflect.ValueOf(val)
+	if !v.IsValid() || v.Type() != t.typ {
+		return "", fmt.Errorf("invalid data type, expect %s, but got %s", t.typ, reflect.TypeOf(val))
+	}
+	return t.enc.Encode(v.Convert(t.underlying).Interface())
+}
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -24,3 +26,134 @@ func hasString(strs []string, s string) bool {
 	}
 	return false
 }
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but nil embedded pointers is allocated if alloc is true, otherwise
+// false is returned.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+type mapEntry struct {
+	Key    string
+	Name   string
+	Values []string
+}
+
+func isMapKeySeparator(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || c == ':' || c == '/'
+}
+
+// mapKey extracts map key from name prefixed by field name, supported formats: prefix[key], prefix.key, and prefixkey
+// if prefix is ended with one of "-_.:/".
+func mapKey(prefix, name string) (string, bool) {
+	if prefix == "" || len(name) <= len(prefix) || name[:len(prefix)] != prefix {
+		return "", false
+	}
+	key := name[len(prefix):]
+	switch {
+	case isMapKeySeparator(prefix[len(prefix)-1]):
+	case key[0] == '[' && key[len(key)-1] == ']':
+		key = key[1 : len(key)-1]
+	case key[0] == '.':
+		key = key[1:]
+	default:
+		return "", false
+	}
+	return key, key != ""
+}
+
+// canonicalMapKey checks whether canonical name is a map entry of prefix, such as "LABELS_ENV" of "labels" for
+// environment variables, the key is verified by canonicalizing the entry name built by mapName.
+func canonicalMapKey(prefix, cname string, canonical func(string) string) bool {
+	cprefix := canonical(prefix)
+	if len(cname) <= len(cprefix)+1 || cname[:len(cprefix)] != cprefix || !isMapKeySeparator(cname[len(cprefix)]) {
+		return false
+	}
+	return canonical(mapName(prefix, cname[len(cprefix)+1:])) == cname
+}
+
+// mapName is the reverse of mapKey, prefix[key] is used if prefix isn't ended with separator.
+func mapName(prefix, key string) string {
+	if prefix != "" && isMapKeySeparator(prefix[len(prefix)-1]) {
+		return prefix + key
+	}
+	return prefix + "[" + key + "]"
+}
+
+// indexedName returns name of element field of structure slices: prefix[index].name.
+func indexedName(prefix string, index int, name string) string {
+	return prefix + "[" + strconv.Itoa(index) + "]." + name
+}
+
+// canonicalSliceElem returns element field name of canonical name prefixed by canonical field name, index may be
+// enclosed in brackets or separated by any of "-_.:/", such as "SERVERS_0_NAME" of "SERVERS".
+func canonicalSliceElem(cprefix, cname string) (string, bool) {
+	if len(cname) <= len(cprefix)+1 || cname[:len(cprefix)] != cprefix {
+		return "", false
+	}
+	rest := cname[len(cprefix):]
+	if rest[0] != '[' && !isMapKeySeparator(rest[0]) {
+		return "", false
+	}
+	rest = rest[1:]
+	n := 0
+	for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+		n++
+	}
+	if n == 0 {
+		return "", false
+	}
+	rest = strings.TrimPrefix(rest[n:], "]")
+	if len(rest) < 2 || !isMapKeySeparator(rest[0]) {
+		return "", false
+	}
+	return rest[1:], true
+}
+
+// sliceIndex parses element index and element field name from name prefixed by field name, supported formats:
+// prefix[index].name and prefix.index.name.
+func sliceIndex(prefix, name string) (index int, elemName string, ok bool) {
+	if len(name) <= len(prefix) || name[:len(prefix)] != prefix {
+		return 0, "", false
+	}
+	rest := name[len(prefix):]
+	var end int
+	switch rest[0] {
+	case '[':
+		end = strings.IndexByte(rest, ']')
+		if end < 0 {
+			return 0, "", false
+		}
+		rest, end = rest[1:], end-1
+		if end+1 < len(rest) && rest[end+1] != '.' && rest[end+1] != '[' {
+			return 0, "", false
+		}
+		elemName = rest[end+1:]
+	case '.':
+		rest = rest[1:]
+		end = strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		elemName = rest[end:]
+	default:
+		return 0, "", false
+	}
+	index, err := strconv.Atoi(rest[:end])
+	if err != nil || index < 0 {
+		return 0, "", false
+	}
+	return index, strings.TrimPrefix(elemName, "."), true
+}
@@ -0,0 +1,253 @@
+package schema
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is reported when decoded value of a field violates the validation rule.
+type ValidationError struct {
+	Rule  string
+	Param string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("validation failed: %s", e.Rule)
+	}
+	return fmt.Sprintf("validation failed: %s=%s", e.Rule, e.Param)
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateRule is parsed from validation tag once, min, max, len and nonempty is applied to the field value, which
+// checks length of string in characters, slice, array and map, or the number itself. oneof, regexp, email, url and
+// uuid is applied to each element of slice and map.
+type validateRule struct {
+	Name    string
+	Param   string
+	Num     float64
+	Options []interface{}
+	Regexp  *regexp.Regexp
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isLengthKind(k reflect.Kind) bool {
+	return k == reflect.String || k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
+
+func numberValue(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+// validateTypes returns the type checked by min, max, len and nonempty, element rules checks elemType.
+func (f *fieldInfo) validateTypes() (typ, elemType reflect.Type) {
+	typ, elemType = f.ValueType, f.ValueType
+	if f.IsMap {
+		typ = f.MapType
+	}
+	if f.IsSlice {
+		elemType = elemType.Elem()
+	}
+	return typ, elemType
+}
+
+// format: rule[;rule]*, rule: min=n|max=n|len=n|oneof=v[|v]*|nonempty|email|url|uuid|regexp=pattern, regexp must be
+// the last rule because the pattern may contain ";".
+func (p *Parser) parseValidateRules(field *fieldInfo, tag string) ([]validateRule, error) {
+	if tag == "" || tag == "-" {
+		return nil, nil
+	}
+	if field.IsFile {
+		return nil, fmt.Errorf("validation of file field is not supported")
+	}
+
+	typ, elemType := field.validateTypes()
+	var rules []validateRule
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ';'); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		r := validateRule{Name: rule}
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			r.Name, r.Param = rule[:i], rule[i+1:]
+		}
+		switch r.Name {
+		case "min", "max", "len":
+			if !isNumberKind(typ.Kind()) && !isLengthKind(typ.Kind()) || r.Name == "len" && !isLengthKind(typ.Kind()) {
+				return nil, fmt.Errorf("rule %s is not supported by type %s", r.Name, typ.String())
+			}
+			n, err := strconv.ParseFloat(r.Param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number of rule %s: %s", r.Name, r.Param)
+			}
+			r.Num = n
+		case "nonempty":
+		case "oneof":
+			for _, opt := range strings.Split(r.Param, "|") {
+				val, err := field.Encoding.Decode(opt)
+				if err != nil {
+					return nil, fmt.Errorf("invalid option of rule oneof: %s, %s", opt, err.Error())
+				}
+				r.Options = append(r.Options, val)
+			}
+		case "regexp", "email", "url", "uuid":
+			if elemType.Kind() != reflect.String {
+				return nil, fmt.Errorf("rule %s is not supported by type %s", r.Name, elemType.String())
+			}
+			if r.Name == "regexp" {
+				re, err := regexp.Compile(r.Param)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern of rule regexp: %s", err.Error())
+				}
+				r.Regexp = re
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule: %s", r.Name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r *validateRule) validateValue(v reflect.Value) bool {
+	var n float64
+	if v.Kind() == reflect.String {
+		// length of string is counted in characters as JSON Schema minLength and maxLength.
+		n = float64(utf8.RuneCountInString(v.String()))
+	} else if isLengthKind(v.Kind()) {
+		n = float64(v.Len())
+	} else if isNumberKind(v.Kind()) {
+		n = numberValue(v)
+	}
+	switch r.Name {
+	case "min":
+		return n >= r.Num
+	case "max":
+		return n <= r.Num
+	case "len":
+		return n == r.Num
+	case "nonempty":
+		if isLengthKind(v.Kind()) {
+			return v.Len() > 0
+		}
+		return !v.IsZero()
+	}
+	return true
+}
+
+func (r *validateRule) validateElem(v reflect.Value) bool {
+	switch r.Name {
+	case "oneof":
+		for _, opt := range r.Options {
+			if reflect.DeepEqual(v.Interface(), opt) {
+				return true
+			}
+		}
+		return false
+	case "regexp":
+		return r.Regexp.MatchString(v.String())
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		return err == nil && addr.Address == v.String()
+	case "url":
+		u, err := url.ParseRequestURI(v.String())
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "uuid":
+		return uuidRegexp.MatchString(v.String())
+	}
+	return true
+}
+
+func (r *validateRule) validateElems(field *fieldInfo, v reflect.Value) bool {
+	validateSlice := func(v reflect.Value) bool {
+		if !field.IsSlice {
+			return r.validateElem(v)
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !r.validateElem(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	if !field.IsMap {
+		return validateSlice(v)
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		if !validateSlice(iter.Value()) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateField checks decoded value of the field by rules, the first violated rule is reported. Null Optional value
+// only violates nonempty.
+func (d *Decoder) validateField(refv reflect.Value, field *fieldInfo) error {
+	if len(field.Rules) == 0 {
+		return nil
+	}
+	fieldv, ok := fieldByIndex(refv, field.Field.Index, false)
+	if !ok {
+		return nil
+	}
+	if field.IsOptional {
+		if fieldv.Field(optionalNullIndex).Bool() {
+			for _, rule := range field.Rules {
+				if rule.Name == "nonempty" {
+					return &ValidationError{Rule: rule.Name}
+				}
+			}
+			return nil
+		}
+		fieldv = fieldv.Field(optionalValueIndex)
+	}
+	if field.IsPtr {
+		if fieldv.IsNil() {
+			return nil
+		}
+		fieldv = fieldv.Elem()
+	}
+	for i := range field.Rules {
+		rule := &field.Rules[i]
+		ok := rule.validateValue(fieldv) && rule.validateElems(field, fieldv)
+		if !ok {
+			return &ValidationError{Rule: rule.Name, Param: rule.Param}
+		}
+	}
+	return nil
+}
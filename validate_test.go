@@ -0,0 +1,127 @@
+package schema_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cosiner/go-schema"
+)
+
+func newValidateDecoder(t *testing.T) *schema.Decoder {
+	p, err := schema.NewParser("schema", []string{"query"}, func(v string) string { return v })
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetValidateTag("validate")
+	err = p.RegisterTypes(schema.BuiltinTypes()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := schema.NewDecoder(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestValidation(t *testing.T) {
+	type Item struct {
+		Code string `schema:"query" validate:"len=3"`
+	}
+	type TestDecoderStruct struct {
+		Name   string                  `schema:"query" validate:"nonempty;max=5"`
+		Page   int                     `schema:"query;default=0" validate:"min=1"`
+		Status string                  `schema:"query" validate:"oneof=on|off"`
+		Email  string                  `schema:"query" validate:"email"`
+		URL    *string                 `schema:"query" validate:"url"`
+		ID     string                  `schema:"query" validate:"uuid"`
+		Tags   []string                `schema:"query" validate:"min=1;regexp=^[a-z;]+$"`
+		Ratio  float64                 `schema:"query" validate:"min=0;max=1"`
+		Labels map[string]string       `schema:"query" validate:"oneof=a|b"`
+		Note   schema.Optional[string] `schema:"query" validate:"nonempty"`
+		Items  []Item
+	}
+	d := newValidateDecoder(t)
+
+	valid := Sources{
+		"query": url.Values{
+			"Name":          []string{"héllo"},
+			"Page":          []string{"2"},
+			"Status":        []string{"on"},
+			"Email":         []string{"a@b.com"},
+			"URL":           []string{"https://example.com/a"},
+			"ID":            []string{"123e4567-e89b-12d3-a456-426614174000"},
+			"Tags":          []string{"a;b", "c"},
+			"Ratio":         []string{"0.5"},
+			"Labels[x]":     []string{"a"},
+			"Note":          []string{"note"},
+			"Items[0].Code": []string{"äöü"},
+		},
+	}
+	var data TestDecoderStruct
+	err := d.Decode(valid, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := Sources{
+		"query": url.Values{
+			"Name":          []string{"toolong"},
+			"Status":        []string{"unknown"},
+			"Email":         []string{"a.com"},
+			"URL":           []string{"/a"},
+			"ID":            []string{"123"},
+			"Tags":          []string{"A"},
+			"Ratio":         []string{"2"},
+			"Labels[x]":     []string{"c"},
+			"Note":          []string{""},
+			"Items[0].Code": []string{"ab"},
+		},
+	}
+	data = TestDecoderStruct{}
+	err = d.Decode(invalid, &data)
+	var errs schema.DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatal("expect decode errors")
+	}
+	var fields []string
+	for _, err := range errs {
+		var validateErr *schema.ValidationError
+		if !errors.As(err, &validateErr) {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		fields = append(fields, err.Field+":"+validateErr.Rule)
+	}
+	expectFields := []string{
+		"Name:max", "Page:min", "Status:oneof", "Email:email", "URL:url", "ID:uuid", "Tags:regexp", "Ratio:max",
+		"Labels:oneof", "Note:nonempty", "Items[0].Code:len",
+	}
+	if !reflect.DeepEqual(fields, expectFields) {
+		t.Fatalf("unexpected validation errors: %v", fields)
+	}
+}
+
+func TestInvalidValidationRules(t *testing.T) {
+	d := newValidateDecoder(t)
+	var (
+		unknownRule struct {
+			Name string `schema:"query" validate:"unknown"`
+		}
+		lenOfNumber struct {
+			Page int `schema:"query" validate:"len=1"`
+		}
+		emailOfNumber struct {
+			Page int `schema:"query" validate:"email"`
+		}
+		invalidOption struct {
+			Page int `schema:"query" validate:"oneof=1|a"`
+		}
+	)
+	for _, v := range []interface{}{&unknownRule, &lenOfNumber, &emailOfNumber, &invalidOption} {
+		if err := d.Decode(Sources{}, v); err == nil {
+			t.Fatalf("invalid rules should be reported: %T", v)
+		}
+	}
+}